@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+)
+
+// applyDocument is the structure used to render the plan and the changes applied as JSON
+type applyDocument struct {
+	Plan    []papertrail.PlanChange `json:"plan"`
+	Applied []papertrail.PlanChange `json:"applied"`
+	Error   string                  `json:"error,omitempty"`
+}
+
+// driftDocument is the structure used to render the differences between a manifest and the account as JSON
+type driftDocument struct {
+	Drift       bool                         `json:"drift"`
+	Differences []papertrail.DriftDifference `json:"differences"`
+}
+
+// errDriftDetected is the error returned when the account doesn't match the manifest
+var errDriftDetected = errors.New("Error: drift detected, the account doesn't match the manifest ")
+
+// manifestFileFlag is the flag used to provide the path of the manifest
+var manifestFileFlag = &cli.StringFlag{
+	Name:     "file",
+	Usage:    "path of the manifest, in YAML or JSON format, declaring the systems, groups and searches",
+	Aliases:  []string{"f"},
+	Required: true,
+}
+
+// applyCommand creates the command used to make the account match the systems, groups and searches declared in a manifest
+func applyCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:  "apply",
+		Usage: "create, update or delete the systems, groups and searches needed to match a manifest",
+		UsageText: "go-papertrail-cli apply --file <manifest> [--prune] [--auto-approve] [--output <format>]\n\n" +
+			"   The changes planned are shown before applying them, asking for confirmation unless\n" +
+			"   --auto-approve is provided. The elements not declared are only deleted with --prune",
+		Flags: []cli.Flag{
+			manifestFileFlag,
+			&cli.BoolFlag{
+				Name:  "prune",
+				Usage: "delete the systems, groups and searches not declared in the manifest",
+				Value: false,
+			},
+			&cli.BoolFlag{
+				Name:    "auto-approve",
+				Usage:   "apply the changes planned without asking for confirmation",
+				Value:   false,
+				Aliases: []string{"yes", "y"},
+			},
+			resultOutputFlag,
+		},
+		Action: func(c *cli.Context) error {
+			if err := checkResultOutput(c); err != nil {
+				return err
+			}
+			jsonOutput := strings.ToLower(c.String("output")) == outputJson
+			if jsonOutput && !c.Bool("auto-approve") {
+				return errors.New("Error: json output can only be used together with auto-approve ")
+			}
+			if _, err := optionalArg(c); err != nil {
+				return err
+			} else if c.NArg() > 0 {
+				return errors.New("Error: unexpected argument " + c.Args().First() + ", the manifest is provided with --file ")
+			}
+			manifest, err := papertrail.ReadManifest(c.String("file"))
+			if err != nil {
+				return err
+			}
+			client, err := commandClient(c, app)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(c)
+			defer cancel()
+			plan, err := client.PlanManifest(ctx, manifest, c.Bool("prune"))
+			if err != nil {
+				return err
+			}
+			if !jsonOutput {
+				printPlan(c.App.Writer, plan)
+			}
+			if len(plan.Changes) == 0 {
+				if jsonOutput {
+					return renderJson(c.App.Writer, applyDocument{Plan: []papertrail.PlanChange{}, Applied: []papertrail.PlanChange{}})
+				}
+				return nil
+			}
+			if err := confirmAction(c, "Apply these changes?"); err != nil {
+				return err
+			}
+			applied, err := client.ApplyPlan(ctx, plan)
+			if jsonOutput {
+				document := applyDocument{Plan: plan.Changes, Applied: applied}
+				if document.Applied == nil {
+					document.Applied = []papertrail.PlanChange{}
+				}
+				if err != nil {
+					document.Error = err.Error()
+				}
+				if errOutput := renderJson(c.App.Writer, document); errOutput != nil {
+					return errOutput
+				}
+				return err
+			}
+			if err != nil {
+				if len(applied) > 0 {
+					log.Printf("Execution stopped before finishing, the following changes were applied\n")
+					for _, change := range applied {
+						log.Printf("- %s of %s\n", change.Action, planChangeName(change))
+					}
+				}
+				return err
+			}
+			log.Printf("Apply complete, %d changes applied\n", len(applied))
+			return nil
+		},
+	}
+}
+
+// importCommand creates the command used to write a manifest declaring the systems, groups and searches of the account
+func importCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:    "import",
+		Aliases: []string{"export"},
+		Usage:   "write a manifest declaring the systems, groups and searches of the account",
+		UsageText: "go-papertrail-cli import [--file <manifest>] [--output <format>]\n\n" +
+			"   The manifest is written to the standard output unless --file is provided, and can be\n" +
+			"   applied with the apply command to reproduce the account",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "file",
+				Usage:   "path of the file where the manifest is written, instead of the standard output",
+				Aliases: []string{"f"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "format of the manifest, possible values yaml or json",
+				Value:   outputYaml,
+				Aliases: []string{"o"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			format := strings.ToLower(c.String("output"))
+			if format != outputYaml && format != outputJson {
+				return errors.New("Not valid option provided for output, the only valid values are: yaml or json ")
+			}
+			if _, err := optionalArg(c); err != nil {
+				return err
+			} else if c.NArg() > 0 {
+				return errors.New("Error: unexpected argument " + c.Args().First() + ", the file is provided with --file ")
+			}
+			client, err := commandClient(c, app)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(c)
+			defer cancel()
+			manifest, err := client.ExportManifest(ctx)
+			if err != nil {
+				return err
+			}
+			var document bytes.Buffer
+			if format == outputJson {
+				err = renderJson(&document, manifest)
+			} else {
+				err = renderYaml(&document, manifest)
+			}
+			if err != nil {
+				return err
+			}
+			if len(c.String("file")) == 0 {
+				_, err = c.App.Writer.Write(document.Bytes())
+				return err
+			}
+			if err := ioutil.WriteFile(c.String("file"), document.Bytes(), 0644); err != nil {
+				return err
+			}
+			log.Printf("Manifest with %d systems, %d groups and %d searches written to %s\n", len(manifest.Systems),
+				len(manifest.Groups), len(manifest.Searches), c.String("file"))
+			return nil
+		},
+	}
+}
+
+// driftCommand creates the command used to compare the account with the systems, groups and searches declared in a manifest
+func driftCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:  "drift",
+		Usage: "compare the systems, groups and searches of the account with a manifest",
+		UsageText: "go-papertrail-cli drift --file <manifest> [--output <format>]\n\n" +
+			"   The elements declared that don't exist, the ones whose fields differ and the ones not declared\n" +
+			"   are reported, exiting with code 2 if there is any difference",
+		Flags: []cli.Flag{
+			manifestFileFlag,
+			&cli.StringFlag{
+				Name:    "output",
+				Usage:   "format of the differences, possible values text or json",
+				Value:   "text",
+				Aliases: []string{"o"},
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if err := checkResultOutput(c); err != nil {
+				return err
+			}
+			if _, err := optionalArg(c); err != nil {
+				return err
+			} else if c.NArg() > 0 {
+				return errors.New("Error: unexpected argument " + c.Args().First() + ", the manifest is provided with --file ")
+			}
+			manifest, err := papertrail.ReadManifest(c.String("file"))
+			if err != nil {
+				return err
+			}
+			client, err := commandClient(c, app)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := commandContext(c)
+			defer cancel()
+			differences, err := client.DetectDrift(ctx, manifest)
+			if err != nil {
+				return err
+			}
+			if strings.ToLower(c.String("output")) == outputJson {
+				err = renderJson(c.App.Writer, driftDocument{Drift: len(differences) > 0, Differences: differences})
+			} else {
+				printDrift(c.App.Writer, differences)
+			}
+			if err != nil {
+				return err
+			}
+			if len(differences) > 0 {
+				return errDriftDetected
+			}
+			return nil
+		},
+	}
+}
+
+// printDrift writes the differences between a manifest and the account, with the values of the fields changed
+// in the account and in the manifest, ending with a summary of the differences
+func printDrift(w io.Writer, differences []papertrail.DriftDifference) {
+	if len(differences) == 0 {
+		fmt.Fprintln(w, "No drift, the account matches the manifest")
+		return
+	}
+	counts := map[string]int{}
+	for _, difference := range differences {
+		counts[difference.Kind]++
+		id := ""
+		if difference.ID != 0 {
+			id = fmt.Sprintf(" (id %d)", difference.ID)
+		}
+		change := papertrail.PlanChange{ItemType: difference.ItemType, Name: difference.Name, Group: difference.Group}
+		fmt.Fprintf(w, "%s %s%s\n", difference.Kind, planChangeName(change), id)
+		for _, field := range difference.Fields {
+			fmt.Fprintf(w, "    %s: %q in the account, %q in the manifest\n", field.Field, field.Before, field.After)
+		}
+	}
+	fmt.Fprintf(w, "\nDrift: %d missing, %d changed, %d unmanaged.\n", counts[papertrail.DriftMissing],
+		counts[papertrail.DriftChanged], counts[papertrail.DriftUnmanaged])
+}
+
+// printPlan writes the changes of the plan like a diff, the elements created preceded by +, the updated
+// by ~ followed by the fields changed, and the deleted by -, ending with a summary of the changes
+func printPlan(w io.Writer, plan *papertrail.Plan) {
+	if len(plan.Changes) == 0 {
+		fmt.Fprintln(w, "No changes, the account matches the manifest")
+		return
+	}
+	symbols := map[string]string{
+		papertrail.PlanActionCreate: "+",
+		papertrail.PlanActionUpdate: "~",
+		papertrail.PlanActionDelete: "-",
+	}
+	for _, change := range plan.Changes {
+		id := ""
+		if change.ID != 0 {
+			id = fmt.Sprintf(" (id %d)", change.ID)
+		}
+		fmt.Fprintf(w, "%s %s %s%s\n", symbols[change.Action], change.Action, planChangeName(change), id)
+		for _, field := range change.Fields {
+			if change.Action == papertrail.PlanActionCreate {
+				fmt.Fprintf(w, "    %s: %q\n", field.Field, field.After)
+			} else {
+				fmt.Fprintf(w, "    %s: %q -> %q\n", field.Field, field.Before, field.After)
+			}
+		}
+		if len(change.Unsupported) > 0 {
+			fmt.Fprintf(w, "    (will be skipped, %s)\n", change.Unsupported)
+		}
+	}
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n", plan.Count(papertrail.PlanActionCreate),
+		plan.Count(papertrail.PlanActionUpdate), plan.Count(papertrail.PlanActionDelete))
+}
+
+// planChangeName returns the type and the name of the element of a change, including the group for the searches
+func planChangeName(change papertrail.PlanChange) string {
+	name := strings.ToLower(change.ItemType) + " '" + change.Name + "'"
+	if len(change.Group) > 0 {
+		name += " of group '" + change.Group + "'"
+	}
+	return name
+}
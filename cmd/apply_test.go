@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runCLIWithResponses runs the cli with the arguments provided against a server that returns, for each
+// path, the body provided for it, returning what has been written to the output and the requests received
+func runCLIWithResponses(t *testing.T, bodies map[string]string, args ...string) (string, []string, error) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body, found := bodies[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	client := papertrail.NewClient(server.URL+"/api/v1/", "token", nil, "", log.New(ioutil.Discard, "", 0))
+	cmd := buildCLI(papertrail.NewApp(client))
+	var output bytes.Buffer
+	cmd.Writer = &output
+	err := cmd.RunContext(context.Background(), append([]string{"go-papertrail-cli"}, args...))
+	return output.String(), requests, err
+}
+
+func TestApply_PlanNotConfirmed(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "papertrail.yaml")
+	err := ioutil.WriteFile(manifest, []byte("groups:\n  - {name: prod, system_wildcard: '*prod*'}\n"+
+		"searches:\n  - {name: errors, group: prod, query: error}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := confirmationInput
+	confirmationInput = strings.NewReader("n\n")
+	defer func() { confirmationInput = input }()
+	bodies := map[string]string{
+		"/api/v1/systems.json":      `[]`,
+		"/api/v1/groups.json":       `[{"id": 10, "name": "prod", "system_wildcard": "*"}]`,
+		"/api/v1/searches.json":     `[]`,
+		"/api/v1/destinations.json": `[]`,
+	}
+	output, requests, err := runCLIWithResponses(t, bodies, "apply", "-f", manifest)
+	if err == nil || !strings.Contains(err.Error(), "action cancelled") {
+		t.Fatalf("expected the apply to be cancelled, obtained %v", err)
+	}
+	expected := "~ update group 'prod' (id 10)\n" +
+		"    system_wildcard: \"*\" -> \"*prod*\"\n" +
+		"+ create search 'errors' of group 'prod'\n" +
+		"    query: \"error\"\n" +
+		"\nPlan: 1 to create, 1 to update, 0 to delete.\n" +
+		"Apply these changes? [y/N]: "
+	if output != expected {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+	for _, request := range requests {
+		if !strings.HasPrefix(request, "GET ") {
+			t.Fatalf("unexpected request %s when the plan is not confirmed", request)
+		}
+	}
+}
+
+func TestImport_Yaml(t *testing.T) {
+	bodies := map[string]string{
+		"/api/v1/systems.json": `[{"id": 2, "name": "web-2", "hostname": "web-2.example.com", "ip_address": null, "syslog": {"port": 1111}},
+			{"id": 1, "name": "db", "hostname": "db.example.com", "ip_address": "10.0.0.2", "syslog": {}}]`,
+		"/api/v1/groups.json":       `[{"id": 10, "name": "prod", "system_wildcard": "*prod*"}]`,
+		"/api/v1/searches.json":     `[{"id": 20, "name": "errors", "query": "error", "group": {"id": 10, "name": "prod"}}]`,
+		"/api/v1/destinations.json": `[{"id": 5, "syslog": {"hostname": "logs.papertrailapp.com", "port": 1111}}]`,
+	}
+	output, _, err := runCLIWithResponses(t, bodies, "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "systems:\n" +
+		"- name: db\n  ip_address: 10.0.0.2\n" +
+		"- name: web-2\n  hostname: web-2.example.com\n  destination_port: 1111\n" +
+		"groups:\n- name: prod\n  system_wildcard: '*prod*'\n" +
+		"searches:\n- name: errors\n  group: prod\n  query: error\n"
+	if output != expected {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+}
+
+func TestDrift_Json(t *testing.T) {
+	manifest := filepath.Join(t.TempDir(), "papertrail.yaml")
+	if err := ioutil.WriteFile(manifest, []byte("groups:\n  - {name: prod, system_wildcard: '*prod*'}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	bodies := map[string]string{
+		"/api/v1/systems.json":      `[]`,
+		"/api/v1/groups.json":       `[{"id": 10, "name": "prod", "system_wildcard": "*"}]`,
+		"/api/v1/searches.json":     `[]`,
+		"/api/v1/destinations.json": `[]`,
+	}
+	output, _, err := runCLIWithResponses(t, bodies, "drift", "-f", manifest, "--output", "json")
+	if exitCodeForError(err) != exitCodeDrift {
+		t.Fatalf("expected the drift to be reported with exit code %d, obtained %v", exitCodeDrift, err)
+	}
+	expected := `{
+  "drift": true,
+  "differences": [
+    {
+      "kind": "changed",
+      "type": "Group",
+      "name": "prod",
+      "id": 10,
+      "fields": [
+        {
+          "field": "system_wildcard",
+          "before": "*",
+          "after": "*prod*"
+        }
+      ]
+    }
+  ]
+}
+`
+	if output != expected {
+		t.Fatalf("unexpected output:\n%s\nexpected:\n%s", output, expected)
+	}
+	bodies["/api/v1/groups.json"] = `[{"id": 10, "name": "prod", "system_wildcard": "*prod*"}]`
+	output, _, err = runCLIWithResponses(t, bodies, "drift", "-f", manifest)
+	if err != nil || output != "No drift, the account matches the manifest\n" {
+		t.Fatalf("expected no drift, obtained %q %v", output, err)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"strconv"
+)
+
+// destinationsCommand creates the command used to consult the log destinations defined in papertrail
+func destinationsCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:  "destinations",
+		Usage: "list or get the log destinations where the systems send their logs",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "list all the log destinations",
+				UsageText: "go-papertrail-cli destinations list [--output <format>] [--columns <columns>]",
+				Flags:     outputFlags(),
+				Action: func(c *cli.Context) error {
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					destinations, err := client.ListDestinations(ctx)
+					if err != nil {
+						return err
+					}
+					return printDestinations(c, destinations, false)
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "show a log destination given its id",
+				UsageText: "go-papertrail-cli destinations get [--output <format>] [--columns <columns>] <id>",
+				Flags:     outputFlags(),
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "destination id")
+					if err != nil {
+						return err
+					}
+					destinationId, err := strconv.Atoi(reference)
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					destination, err := client.GetDestination(ctx, destinationId)
+					if err != nil {
+						return err
+					}
+					return printDestinations(c, []papertrail.Destination{*destination}, true)
+				},
+			},
+		},
+	}
+}
+
+// destinationsView is the view used to render the log destinations
+var destinationsView = view{
+	columns: []column{
+		{name: "id", header: "ID", value: func(e interface{}) interface{} { return e.(papertrail.Destination).ID }},
+		{name: "hostname", header: "HOSTNAME", value: func(e interface{}) interface{} { return e.(papertrail.Destination).Syslog.Hostname }},
+		{name: "port", header: "PORT", value: func(e interface{}) interface{} { return e.(papertrail.Destination).Syslog.Port }},
+		{name: "description", header: "DESCRIPTION", value: func(e interface{}) interface{} { return e.(papertrail.Destination).Syslog.Description }},
+		{name: "filter", header: "FILTER", value: func(e interface{}) interface{} { return e.(papertrail.Destination).Filter }},
+	},
+	defaultColumns: []string{"id", "hostname", "port", "description"},
+}
+
+// printDestinations renders the log destinations provided in the output format selected
+func printDestinations(c *cli.Context, destinations []papertrail.Destination, single bool) error {
+	elements := make([]interface{}, 0, len(destinations))
+	for _, destination := range destinations {
+		elements = append(elements, destination)
+	}
+	return render(c, destinationsView, elements, single)
+}
@@ -3,7 +3,8 @@ NAME:
    go-papertrail-cli - interacts with papertrail through its api to perform both log collection actions and the creation/deletion of systems, groups and saved searches
 
 USAGE:
-   go-papertrail-cli [--group-name <group-name>] [--system-wildcard <wildcard>] [--search <search-name>] [--query <query>] [--action <action>] [--delete-all-searches <delete-all-searches>] [--delete-only-searches <delete-only-searches>] [--delete-all-systems <delete-all-systems>]  [--delete-only-systems <delete-only-systems>][--start-date <start-date>] [--end-date <end-date>] [--path <path>]
+   go-papertrail-cli [--timeout <timeout>] [--strict-schema] <command> <subcommand> [options] [arguments]
+   go-papertrail-cli [legacy options] (deprecated)
 
 VERSION:
    1.3.0
@@ -12,9 +13,20 @@ AUTHOR:
    Xoan Mallon <xoanmallon@gmail.com>
 
 COMMANDS:
-   help, h  Shows a list of commands or help for one command
+   systems         list, get, create, update or delete the systems sending logs to papertrail
+   groups          list, get, create, update or delete the groups of systems
+   searches        list, get, create, update or delete the saved searches
+   events          obtain or follow the log events stored in papertrail
+   destinations    list or get the log destinations where the systems send their logs
+   apply           create, update or delete the systems, groups and searches needed to match a manifest
+   import, export  write a manifest declaring the systems, groups and searches of the account
+   drift           compare the systems, groups and searches of the account with a manifest
+   help, h         Shows a list of commands or help for one command
 
 GLOBAL OPTIONS:
+   --timeout value                     maximum duration of the execution, e.g. 30s or 5m (0 means no timeout) (default: 0s)
+   --strict-schema                     Reject the responses of papertrail's API containing fields unknown to the cli (default: false)
+   --output value, -o value            format of the result of the actions performed, possible values text (logged) or json (written to the standard output) (default: "text")
    --group-name value, -g value        group defined or to be defined in papertrail (default: "my-log-group")
    --system-wildcard value, -w value   wildcard to be applied on the systems defined in papertrail (default: "*")
    --destination-port value, -p value  destination port for sending the logs of the indicated system/s (default: 0)
@@ -31,6 +43,7 @@ GLOBAL OPTIONS:
    --start-date value, -s value        filter only from a date specified ('mm/dd/yyyy hh:mm:ss' format UTC time) (default: $ACTUAL_DATE - 8hours)
    --end-date value, -e value          filter only until a date specified ('mm/dd/yyyy hh:mm:ss' format UTC time) (default: $ACTUAL_DATE)
    --path value, -P value              path where to store the logs (default: "/tmp")
+   --no-update                         Indicates if the existing group and search whose system wildcard or query differ from the ones specified are left as they are when creating, only warning about the differences (default: false)
    --help, -h                          show help (default: false)
    --version, -v                       print the version (default: false)
 */
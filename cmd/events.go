@@ -0,0 +1,488 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventsCommand creates the command used to obtain the log events stored in papertrail
+func eventsCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:  "events",
+		Usage: "obtain or follow the log events stored in papertrail",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "search",
+				Usage: "save in files the log events matching a query or a saved search between two dates",
+				UsageText: "go-papertrail-cli events search [--group <id|name> | --system <systems>] [--query <query> | --search <id|name>] " +
+					"[--severity <severities>] [--facility <facilities>] [--program <programs>] [--host <hosts>] [--exclude-program <programs>] " +
+					"[--start-date <start-date>] [--end-date <end-date>] [--last <duration>] [--tz <time zone>] [--path <path>] [--format <format>] [--compress <compression>] " +
+					"[--max-file-size <size>] [--max-file-events <count>] [--file-interval <interval>] [--manifest] " +
+					"[--filename-template <template>] [--overwrite | --append] [--resume] [--shards <count>] [--concurrency <count>] " +
+					"[--output <format>]",
+				Flags: append(append(append(append(eventsTargetFlags(), eventsFilterFlags()...), eventsFormatFlags(papertrail.EventsFormatRaw)...),
+					eventsExportFlags()...),
+					&cli.StringFlag{
+						Name: "start-date",
+						Usage: "filter only from a date specified, in 'mm/dd/yyyy hh:mm:ss', RFC 3339 or 'yyyy-mm-dd [hh:mm:ss]' format " +
+							"or relative to the current time like -2h, '30m ago', yesterday or 'today 09:00'",
+						Value:   "-8h",
+						Aliases: []string{"s", "since"},
+					},
+					&cli.StringFlag{
+						Name:    "end-date",
+						Usage:   "filter only until a date specified, in the same formats as start-date",
+						Value:   "now",
+						Aliases: []string{"e", "until"},
+					},
+					&cli.StringFlag{
+						Name:  "last",
+						Usage: "filter only the events received during the last period specified until now, e.g. 15m, 2h or 1d",
+					},
+					resultOutputFlag,
+				),
+				Action: func(c *cli.Context) error {
+					if err := checkResultOutput(c); err != nil {
+						return err
+					}
+					formatter, err := eventsFormatter(c)
+					if err != nil {
+						return err
+					}
+					export, err := eventsExport(c, formatter)
+					if err != nil {
+						return err
+					}
+					if export.Filter, err = eventsFilter(c); err != nil {
+						return err
+					}
+					startDateUnix, endDateUnix, err := eventsDateRange(c)
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					target, err := resolveEventsTarget(ctx, c, client)
+					if err != nil {
+						return err
+					}
+					var item *papertrail.Item
+					if len(target.systemIds) > 0 {
+						item, err = client.SearchSystemsEvents(ctx, target.groupName, target.systemIds, target.searchName, target.query,
+							startDateUnix, endDateUnix, *export)
+					} else {
+						item, err = client.SearchEvents(ctx, target.groupName, target.groupId, target.searchName, target.query,
+							startDateUnix, endDateUnix, *export)
+					}
+					var items []papertrail.Item
+					if item != nil {
+						items = append(items, *item)
+					}
+					action := "obtain"
+					if err != nil {
+						printPartialResultIfErrorsDetected(err, items)
+					} else {
+						log.Printf("%s saved in file %s", item.ItemType, item.ItemName)
+					}
+					if errOutput := printResult(c, &action, items, err); errOutput != nil {
+						return errOutput
+					}
+					return err
+				},
+			},
+			{
+				Name:  "tail",
+				Usage: "follow the log events matching a query or a saved search as they arrive, printing them to the standard output",
+				UsageText: "go-papertrail-cli events tail [--group <id|name> | --system <systems>] [--query <query> | --search <id|name>] " +
+					"[--severity <severities>] [--facility <facilities>] [--program <programs>] [--host <hosts>] [--exclude-program <programs>] " +
+					"[--min-id <id>] [--interval <interval>] [--max-interval <interval>] [--format <format>] [--tz <time zone>]",
+				Flags: append(append(append(eventsTargetFlags(), eventsFilterFlags()...), eventsFormatFlags(papertrail.EventsFormatPapertrail)...),
+					&cli.StringFlag{
+						Name:  "min-id",
+						Usage: "id of the last event received in a previous execution, from which the events are followed",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "interval between the requests to papertrail when new events arrive",
+						Value: 2 * time.Second,
+					},
+					&cli.DurationFlag{
+						Name:  "max-interval",
+						Usage: "maximum interval between the requests to papertrail, reached doubling the interval while no new events arrive",
+						Value: 30 * time.Second,
+					},
+				),
+				Action: func(c *cli.Context) error {
+					formatter, err := eventsFormatter(c)
+					if err != nil {
+						return err
+					}
+					filter, err := eventsFilter(c)
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					target, err := resolveEventsTarget(ctx, c, client)
+					if err != nil {
+						return err
+					}
+					if err := formatter.WriteHeader(c.App.Writer); err != nil {
+						return err
+					}
+					handler := func(event papertrail.Events) error {
+						if !filter.Match(event) {
+							return nil
+						}
+						return formatter.WriteEvent(c.App.Writer, event)
+					}
+					var cursor string
+					if len(target.systemIds) > 0 {
+						cursor, err = client.TailSystemsEvents(ctx, target.systemIds, target.query, c.String("min-id"),
+							c.Duration("interval"), c.Duration("max-interval"), handler)
+					} else {
+						cursor, err = client.TailEvents(ctx, target.groupId, target.query, c.String("min-id"),
+							c.Duration("interval"), c.Duration("max-interval"), handler)
+					}
+					if len(cursor) > 0 {
+						log.Printf("Events followed until the event with id %s, resume with --min-id %s\n", cursor, cursor)
+					}
+					return err
+				},
+			},
+		},
+	}
+}
+
+// eventsTarget is the structure used to represent the events to be obtained
+type eventsTarget struct {
+	groupId    int
+	groupName  string
+	systemIds  []int64
+	searchName string
+	query      string
+}
+
+// eventsTargetFlags returns the flags used to indicate the events to be obtained
+func eventsTargetFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "group",
+			Usage:   "id or name of the group whose systems are searched, all the systems are searched if not provided",
+			Aliases: []string{"g"},
+		},
+		&cli.StringFlag{
+			Name:    "query",
+			Usage:   "query to be performed on the logs",
+			Value:   "*",
+			Aliases: []string{"q"},
+		},
+		&cli.StringFlag{
+			Name:    "search",
+			Usage:   "id or name of the saved search whose query and group are used",
+			Aliases: []string{"S"},
+		},
+		&cli.StringFlag{
+			Name:  "system",
+			Usage: "comma separated list of ids, names, hostnames or IP addresses of the systems searched instead of a group",
+		},
+	}
+}
+
+// eventsFilterFlags returns the flags used to select the log events written once they have been obtained from papertrail
+func eventsFilterFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "severity",
+			Usage: "write only the events with a severity in a range like err..emerg or a comma separated list like warning,err",
+		},
+		&cli.StringFlag{
+			Name:  "facility",
+			Usage: "write only the events with one of the facilities of a comma separated list, e.g. local0,local1",
+		},
+		&cli.StringFlag{
+			Name:  "program",
+			Usage: "write only the events whose program matches one of the patterns of a comma separated list, e.g. nginx,php-*",
+		},
+		&cli.StringFlag{
+			Name:  "host",
+			Usage: "write only the events whose hostname or system name matches one of the patterns of a comma separated list, e.g. web-*",
+		},
+		&cli.StringFlag{
+			Name:  "exclude-facility",
+			Usage: "discard the events with one of the facilities of a comma separated list",
+		},
+		&cli.StringFlag{
+			Name:  "exclude-program",
+			Usage: "discard the events whose program matches one of the patterns of a comma separated list, e.g. cron",
+		},
+		&cli.StringFlag{
+			Name:  "exclude-host",
+			Usage: "discard the events whose hostname or system name matches one of the patterns of a comma separated list",
+		},
+	}
+}
+
+// eventsFilter returns the filter of the log events indicated through the filter flags, nil if none has been provided
+func eventsFilter(c *cli.Context) (*papertrail.EventsFilter, error) {
+	set := false
+	for _, name := range []string{"severity", "facility", "program", "host", "exclude-facility", "exclude-program", "exclude-host"} {
+		set = set || c.IsSet(name)
+	}
+	if !set {
+		return nil, nil
+	}
+	return papertrail.NewEventsFilter(c.String("severity"), listFlag(c, "facility"), listFlag(c, "program"), listFlag(c, "host"),
+		listFlag(c, "exclude-facility"), listFlag(c, "exclude-program"), listFlag(c, "exclude-host"))
+}
+
+// listFlag returns the values of the comma separated list provided through the flag with the name provided
+func listFlag(c *cli.Context, name string) []string {
+	if len(strings.TrimSpace(c.String(name))) == 0 {
+		return nil
+	}
+	var values []string
+	for _, value := range strings.Split(c.String(name), ",") {
+		values = append(values, strings.TrimSpace(value))
+	}
+	return values
+}
+
+// eventsFormatFlags returns the flags used to select how the log events are written,
+// using the format provided by default
+func eventsFormatFlags(defaultFormat string) []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "format in which the events are written, possible values raw, papertrail, jsonl, csv, logfmt, template, rfc5424 or rfc3164",
+			Value: defaultFormat,
+		},
+		&cli.StringFlag{
+			Name:  "columns",
+			Usage: "comma separated list of the fields of the events written in csv and logfmt formats, e.g. received_at,hostname,message",
+		},
+		&cli.StringFlag{
+			Name:  "template",
+			Usage: "Go template executed with each event in template format, e.g. '{{.Hostname}} {{.Message}}'",
+		},
+		&cli.BoolFlag{
+			Name:  "octet-counting",
+			Usage: "precede each event with its length instead of ending it with a new line, as expected by syslog consumers over TCP",
+		},
+		&cli.StringFlag{
+			Name: "tz",
+			Usage: "time zone in which the dates provided are interpreted and the times of the events are written, e.g. UTC, Local, " +
+				"Europe/Madrid or +02:00 (by default the dates are interpreted in UTC and the events written as papertrail returns them)",
+		},
+	}
+}
+
+// eventsFormatter returns the formatter of the log events selected through the format flags
+func eventsFormatter(c *cli.Context) (papertrail.EventsFormatter, error) {
+	formatter, err := papertrail.NewEventsFormatter(c.String("format"), listFlag(c, "columns"), c.String("template"))
+	if err != nil {
+		return nil, err
+	}
+	if c.IsSet("tz") {
+		loc, err := papertrail.LoadTimeZone(c.String("tz"))
+		if err != nil {
+			return nil, err
+		}
+		formatter = papertrail.NewTimeZoneFormatter(formatter, loc)
+	}
+	if c.Bool("octet-counting") {
+		formatter = papertrail.NewOctetCountingFormatter(formatter)
+	}
+	return formatter, nil
+}
+
+// eventsDateRange returns the unix timestamps of the dates between which the events are obtained, indicated through
+// the start and end dates or the last period until now, interpreting them in the time zone indicated if any
+func eventsDateRange(c *cli.Context) (int64, int64, error) {
+	loc, err := papertrail.LoadTimeZone(c.String("tz"))
+	if err != nil {
+		return 0, 0, err
+	}
+	startDate, endDate := c.String("start-date"), c.String("end-date")
+	if c.IsSet("last") {
+		if c.IsSet("start-date") || c.IsSet("end-date") {
+			return 0, 0, errors.New("Error: last can't be provided together with start-date or end-date ")
+		}
+		if _, err := papertrail.ParseRelativeDuration(c.String("last")); err != nil {
+			return 0, 0, err
+		}
+		startDate, endDate = "-"+strings.TrimSpace(c.String("last")), "now"
+	}
+	return papertrail.GetDateRangeUnixTimeInLocation(startDate, endDate, loc)
+}
+
+// eventsExportFlags returns the flags used to indicate how the log events obtained are saved in files
+func eventsExportFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "path",
+			Usage:   "path where to store the logs",
+			Value:   "/tmp",
+			Aliases: []string{"P"},
+		},
+		&cli.StringFlag{
+			Name:  "compress",
+			Usage: "compression of the files, possible values none, gzip or zstd",
+			Value: papertrail.CompressionNone,
+		},
+		&cli.StringFlag{
+			Name:  "max-file-size",
+			Usage: "maximum size of the events saved in a file before compressing them, e.g. 500MB or 2GB, starting a new file when it is reached",
+		},
+		&cli.IntFlag{
+			Name:  "max-file-events",
+			Usage: "maximum number of events saved in a file, starting a new file when it is reached",
+		},
+		&cli.DurationFlag{
+			Name:  "file-interval",
+			Usage: "save in a different file the events received in each interval, e.g. 1h saves a file per hour",
+		},
+		&cli.BoolFlag{
+			Name:  "manifest",
+			Usage: "write a manifest listing the files even if the events are saved in a single file (always written when they are split)",
+		},
+		&cli.StringFlag{
+			Name:  "filename-template",
+			Usage: "template of the name of the files, with the placeholders {group}, {search}, {query_hash}, {start} and {end}",
+			Value: papertrail.DefaultFilenameTemplate,
+		},
+		&cli.StringFlag{
+			Name:  "filename-time-format",
+			Usage: "Go layout of the dates in the name of the files",
+			Value: papertrail.DefaultFilenameTimeFormat,
+		},
+		&cli.BoolFlag{
+			Name:  "overwrite",
+			Usage: "replace the files that already exist, by default the search fails if a file already exists",
+		},
+		&cli.BoolFlag{
+			Name:  "append",
+			Usage: "add the events to the files that already exist, by default the search fails if a file already exists",
+		},
+		&cli.BoolFlag{
+			Name:  "resume",
+			Usage: "continue an interrupted search from its checkpoint, which must have been performed with the same flags",
+		},
+		&cli.IntFlag{
+			Name:  "shards",
+			Usage: "number of sub-windows in which the dates are split to obtain their events concurrently",
+			Value: 1,
+		},
+		&cli.IntFlag{
+			Name:        "concurrency",
+			Usage:       "maximum number of shards whose events are obtained at the same time",
+			DefaultText: "the number of shards, at most 4",
+		},
+	}
+}
+
+// eventsExport returns how the log events are saved in files from the export flags, using the formatter provided
+func eventsExport(c *cli.Context, formatter papertrail.EventsFormatter) (*papertrail.EventsExport, error) {
+	export := papertrail.NewEventsExport(c.String("path"), formatter)
+	export.Compression = c.String("compress")
+	export.MaxFileEvents = c.Int("max-file-events")
+	export.FileInterval = c.Duration("file-interval")
+	export.Manifest = c.Bool("manifest")
+	export.FilenameTemplate = c.String("filename-template")
+	export.FilenameTimeFormat = c.String("filename-time-format")
+	if c.Bool("overwrite") && c.Bool("append") {
+		return nil, errors.New("Error: only one of overwrite or append can be provided ")
+	}
+	export.Resume = c.Bool("resume")
+	export.Shards = c.Int("shards")
+	export.Concurrency = c.Int("concurrency")
+	export.Mode = papertrail.FileModeFail
+	if c.Bool("overwrite") {
+		export.Mode = papertrail.FileModeOverwrite
+	} else if c.Bool("append") {
+		export.Mode = papertrail.FileModeAppend
+	}
+	if c.IsSet("max-file-size") {
+		size, err := parseSize(c.String("max-file-size"))
+		if err != nil {
+			return nil, err
+		}
+		export.MaxFileSize = size
+	}
+	return export, nil
+}
+
+// sizeUnits are the multipliers of the units that can be used in sizes
+var sizeUnits = map[string]int64{"": 1, "B": 1, "K": 1 << 10, "KB": 1 << 10, "M": 1 << 20, "MB": 1 << 20, "G": 1 << 30, "GB": 1 << 30}
+
+// parseSize returns the number of bytes of a size provided as a number optionally followed by
+// a unit, e.g. 500MB. The units are multiples of 1024 bytes
+func parseSize(size string) (int64, error) {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	unit := strings.TrimLeft(size, "0123456789")
+	number, err := strconv.ParseInt(strings.TrimSuffix(size, unit), 10, 64)
+	multiplier, ok := sizeUnits[strings.TrimSpace(unit)]
+	if err != nil || !ok || number <= 0 {
+		return 0, errors.New("Error: not valid size " + size + ", it must be a positive number optionally followed by B, KB, MB or GB ")
+	}
+	return number * multiplier, nil
+}
+
+// resolveEventsTarget obtains the group and the query of the events to be obtained from
+// the group, query and saved search flags. All the systems are used if no group is provided
+func resolveEventsTarget(ctx context.Context, c *cli.Context, client *papertrail.Client) (*eventsTarget, error) {
+	if c.IsSet("search") && c.IsSet("query") {
+		return nil, errors.New("Error: only one of query or saved search can be provided ")
+	}
+	target := &eventsTarget{groupName: "all-systems", searchName: "query", query: c.String("query")}
+	group, err := groupFromFlag(ctx, c, client, "group")
+	if err != nil {
+		return nil, err
+	}
+	if group != nil {
+		target.groupId = group.ID
+		target.groupName = group.Name
+	}
+	if c.IsSet("search") {
+		search, err := client.FindSearch(ctx, c.String("search"), target.groupId)
+		if err != nil {
+			return nil, err
+		}
+		target.searchName = search.Name
+		target.query = search.Query
+		target.groupId = search.Group.ID
+		target.groupName = search.Group.Name
+	}
+	if c.IsSet("system") {
+		// The systems replace the group of the saved search, if any
+		if c.IsSet("group") {
+			return nil, errors.New("Error: only one of group or systems can be provided ")
+		}
+		systems, err := client.FindSystems(ctx, listFlag(c, "system"))
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, system := range systems {
+			target.systemIds = append(target.systemIds, system.ID)
+			names = append(names, system.Name)
+		}
+		target.groupId = 0
+		target.groupName = strings.Join(names, "+")
+	}
+	return target, nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{"500": 500, "10B": 10, "64kb": 64 << 10, "500MB": 500 << 20, "2 GB": 2 << 30}
+	for size, expected := range tests {
+		bytes, err := parseSize(size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes != expected {
+			t.Errorf("expected %d bytes for %s, obtained %d", expected, size, bytes)
+		}
+	}
+	for _, size := range []string{"", "MB", "-1GB", "10TB", "1.5GB"} {
+		if _, err := parseSize(size); err == nil {
+			t.Errorf("expected an error for the size %s", size)
+		}
+	}
+}
+
+func TestEventsSearch_LastWithDates(t *testing.T) {
+	for _, args := range [][]string{{"--last", "15m", "--since", "-1h"}, {"--until", "now", "--last", "15m"}} {
+		_, err := runCLI(t, "", append([]string{"events", "search"}, args...)...)
+		if err == nil || err.Error() != "Error: last can't be provided together with start-date or end-date " {
+			t.Errorf("expected an error using %v, obtained %v", args, err)
+		}
+	}
+}
+
+func TestEventsSearch_GroupRequestedOnce(t *testing.T) {
+	bodies := map[string]string{
+		"/api/v1/groups.json":        `[{"id": 10, "name": "prod", "system_wildcard": "*"}]`,
+		"/api/v1/events/search.json": `{"events": [], "reached_beginning": true}`,
+	}
+	output, requests, err := runCLIWithResponses(t, bodies, "events", "search", "--group", "prod", "--path", t.TempDir(),
+		"--start-date", "2020-05-04", "--end-date", "2020-05-05", "--output", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "prod_query_") {
+		t.Errorf("expected the name of the group in the file of the events, obtained %s", output)
+	}
+	for _, request := range requests {
+		if strings.HasPrefix(request, "GET /api/v1/groups/") {
+			t.Errorf("unexpected request %s, the group has already been obtained", request)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"strconv"
+)
+
+// groupsCommand creates the command used to manage the groups of systems defined in papertrail
+func groupsCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:  "groups",
+		Usage: "list, get, create, update or delete the groups of systems",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "list all the groups",
+				UsageText: "go-papertrail-cli groups list [--output <format>] [--columns <columns>]",
+				Flags:     outputFlags(),
+				Action: func(c *cli.Context) error {
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					groups, err := client.ListGroups(ctx)
+					if err != nil {
+						return err
+					}
+					return printGroups(c, groups, false)
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "show a group given its id or name",
+				UsageText: "go-papertrail-cli groups get [--output <format>] [--columns <columns>] <id|name>",
+				Flags:     outputFlags(),
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "group")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					group, err := client.FindGroup(ctx, reference)
+					if err != nil {
+						return err
+					}
+					return printGroups(c, []papertrail.GroupObject{*group}, true)
+				},
+			},
+			{
+				Name:      "create",
+				Usage:     "create a group",
+				UsageText: "go-papertrail-cli groups create [--system-wildcard <wildcard>] <name>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:    "system-wildcard",
+						Usage:   "wildcard to be applied on the systems defined in papertrail",
+						Value:   "*",
+						Aliases: []string{"w"},
+					},
+				}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					name, err := requiredArg(c, "group name")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					group, err := client.CreateGroup(ctx, name, c.String("system-wildcard"))
+					if err != nil {
+						return err
+					}
+					return printGroups(c, []papertrail.GroupObject{*group}, true)
+				},
+			},
+			{
+				Name:      "update",
+				Usage:     "change the name and/or the system wildcard of a group given its id or name",
+				UsageText: "go-papertrail-cli groups update [--name <name>] [--system-wildcard <wildcard>] <id|name>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "new name of the group",
+					},
+					&cli.StringFlag{
+						Name:    "system-wildcard",
+						Usage:   "new wildcard to be applied on the systems defined in papertrail",
+						Aliases: []string{"w"},
+					},
+				}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "group")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					group, err := client.FindGroup(ctx, reference)
+					if err != nil {
+						return err
+					}
+					name := group.Name
+					if c.IsSet("name") {
+						name = c.String("name")
+					}
+					systemWildcard := group.SystemWildcard
+					if c.IsSet("system-wildcard") {
+						systemWildcard = c.String("system-wildcard")
+					}
+					group, err = client.UpdateGroup(ctx, group.ID, name, systemWildcard)
+					if err != nil {
+						return err
+					}
+					return printGroups(c, []papertrail.GroupObject{*group}, true)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a group and its saved searches given its id or name",
+				UsageText: "go-papertrail-cli groups delete [--yes] <id|name>",
+				Flags:     []cli.Flag{yesFlag},
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "group")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					group, err := client.FindGroup(ctx, reference)
+					if err != nil {
+						return err
+					}
+					err = confirmAction(c, "Delete group '"+group.Name+"' with id "+strconv.Itoa(group.ID)+" and all its saved searches?")
+					if err != nil {
+						return err
+					}
+					return client.DeleteGroup(ctx, group.ID)
+				},
+			},
+		},
+	}
+}
+
+// groupsView is the view used to render the groups
+var groupsView = view{
+	columns: []column{
+		{name: "id", header: "ID", value: func(e interface{}) interface{} { return e.(papertrail.GroupObject).ID }},
+		{name: "name", header: "NAME", value: func(e interface{}) interface{} { return e.(papertrail.GroupObject).Name }},
+		{name: "system_wildcard", header: "SYSTEM WILDCARD", value: func(e interface{}) interface{} { return e.(papertrail.GroupObject).SystemWildcard }},
+		{name: "system_count", header: "SYSTEMS", value: func(e interface{}) interface{} { return len(e.(papertrail.GroupObject).Systems) }},
+	},
+	defaultColumns: []string{"id", "name", "system_wildcard", "system_count"},
+}
+
+// printGroups renders the groups provided in the output format selected
+func printGroups(c *cli.Context, groups []papertrail.GroupObject, single bool) error {
+	elements := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		elements = append(elements, group)
+	}
+	return render(c, groupsView, elements, single)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// legacyFlagNames are the names of the flags of the invocation without subcommands
+var legacyFlagNames = []string{"group-name", "system-wildcard", "destination-port", "destination-id", "ip-address",
+	"system-type", "search", "query", "action", "delete-all-searches", "delete-only-searches", "delete-all-systems",
+	"delete-only-systems", "start-date", "end-date", "path", "no-update"}
+
+// legacyFlags returns the flags of the invocation without subcommands, kept to
+// maintain the compatibility with the versions previous to the subcommands
+func legacyFlags() []cli.Flag {
+	return []cli.Flag{
+		resultOutputFlag,
+
+		&cli.StringFlag{
+			Name:    "group-name",
+			Usage:   "group defined or to be defined in papertrail",
+			Value:   "my-log-group",
+			Aliases: []string{"g"},
+		},
+
+		&cli.StringFlag{
+			Name:    "system-wildcard",
+			Usage:   "wildcard to be applied on the systems defined in papertrail",
+			Value:   "*",
+			Aliases: []string{"w"},
+		},
+
+		&cli.IntFlag{
+			Name:    "destination-port",
+			Usage:   "destination port for sending the logs of the indicated system/s",
+			Value:   0,
+			Aliases: []string{"p"},
+		},
+
+		&cli.IntFlag{
+			Name:    "destination-id",
+			Usage:   "destination id for sending the logs of the indicated system/s",
+			Value:   0,
+			Aliases: []string{"I"},
+		},
+
+		&cli.StringFlag{
+			Name:    "ip-address",
+			Usage:   "source ip address from sending the logs of the indicated system/s",
+			Value:   "",
+			Aliases: []string{"i"},
+		},
+
+		&cli.StringFlag{
+			Name:    "system-type",
+			Usage:   "Type of system, can be hostname or ip-address",
+			Value:   "hostname",
+			Aliases: []string{"t"},
+		},
+
+		&cli.StringFlag{
+			Name:    "search",
+			Usage:   "name of saved search to be performed on logs or to be created on a group",
+			Value:   "default search",
+			Aliases: []string{"S"},
+		},
+
+		&cli.StringFlag{
+			Name:    "query",
+			Usage:   "query to be performed on the group of logs or applied on the search to be created",
+			Value:   "*",
+			Aliases: []string{"q"},
+		},
+
+		&cli.StringFlag{
+			Name:    "action",
+			Usage:   "Action to be performed with the information provided for papertrail, possible values only c(create), o(obtain) or d(delete)",
+			Value:   "c",
+			Aliases: []string{"a"},
+		},
+
+		&cli.BoolFlag{
+			Name:    "delete-all-searches",
+			Usage:   "Indicates if all searches in a group or a specific search are going to be deleted",
+			Value:   false,
+			Aliases: []string{"d"},
+		},
+
+		&cli.BoolFlag{
+			Name:  "delete-only-searches",
+			Usage: "Indicates if only searches specified are going to be deleted",
+			Value: false,
+		},
+
+		&cli.BoolFlag{
+			Name:    "delete-all-systems",
+			Usage:   "Indicates if all systems specified are going to be deleted",
+			Value:   true,
+			Aliases: []string{"D"},
+		},
+
+		&cli.BoolFlag{
+			Name:  "delete-only-systems",
+			Usage: "Indicates if only systems specified are going to be deleted",
+			Value: false,
+		},
+
+		&cli.StringFlag{
+			Name:        "start-date",
+			Usage:       "filter only from a date specified ('mm/dd/yyyy hh:mm:ss' format UTC time)",
+			DefaultText: "$ACTUAL_DATE - 8hours",
+			Value:       "-8h",
+			Aliases:     []string{"s"},
+		},
+
+		&cli.StringFlag{
+			Name:        "end-date",
+			Usage:       "filter only until a date specified ('mm/dd/yyyy hh:mm:ss' format UTC time)",
+			DefaultText: "$ACTUAL_DATE",
+			Value:       "now",
+			Aliases:     []string{"e"},
+		},
+
+		&cli.StringFlag{
+			Name:    "path",
+			Usage:   "path where to store the logs",
+			Value:   "/tmp",
+			Aliases: []string{"P"},
+		},
+
+		&cli.BoolFlag{
+			Name: "no-update",
+			Usage: "Indicates if the existing group and search whose system wildcard or query differ from the ones " +
+				"specified are left as they are when creating, only warning about the differences",
+			Value: false,
+		},
+	}
+}
+
+// legacyAction returns the action performed when the cli is invoked without subcommands. The
+// deprecated flat invocation is still supported, warning about the equivalent subcommands to use
+func legacyAction(app *papertrail.App) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		if !legacyFlagsSet(c) {
+			return cli.ShowAppHelp(c)
+		}
+		if err := checkResultOutput(c); err != nil {
+			return err
+		}
+		options := &papertrail.Options{
+			GroupName:              c.String("group-name"),
+			SystemWildcard:         c.String("system-wildcard"),
+			DestinationPort:        c.Int("destination-port"),
+			DestinationId:          c.Int("destination-id"),
+			IpAddress:              c.String("ip-address"),
+			SystemType:             c.String("system-type"),
+			Search:                 c.String("search"),
+			Query:                  c.String("query"),
+			Action:                 c.String("action"),
+			DeleteAllSystems:       c.Bool("delete-all-systems"),
+			DeleteOnlySystems:      c.Bool("delete-only-systems"),
+			DeleteAllSearches:      c.Bool("delete-all-searches"),
+			DeleteOnlySearches:     c.Bool("delete-only-searches"),
+			StartDate:              c.String("start-date"),
+			EndDate:                c.String("end-date"),
+			Path:                   c.String("path"),
+			NoUpdate:               c.Bool("no-update"),
+			SystemWildcardProvided: c.IsSet("system-wildcard"),
+			QueryProvided:          c.IsSet("query"),
+		}
+		printDeprecationWarning(options)
+
+		configureClient(c, app)
+		ctx, cancel := commandContext(c)
+		defer cancel()
+		papertrailActions, action, err := app.PapertrailActions(ctx, options)
+		printFinalResultIfNotErrorsDetected(err, action, papertrailActions)
+		printPartialResultIfErrorsDetected(err, papertrailActions)
+		if errOutput := printResult(c, action, papertrailActions, err); errOutput != nil {
+			return errOutput
+		}
+		return err
+	}
+}
+
+// legacyFlagsSet checks if any of the flags of the invocation without subcommands has been provided
+func legacyFlagsSet(c *cli.Context) bool {
+	for _, name := range legacyFlagNames {
+		if c.IsSet(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// printDeprecationWarning warns that the invocation without subcommands is
+// deprecated, showing the subcommands equivalent to the options provided
+func printDeprecationWarning(options *papertrail.Options) {
+	log.Printf("Warning: the invocation without subcommands is deprecated and will be removed in a future version, " +
+		"the equivalent commands are:\n")
+	for _, command := range legacyEquivalentCommands(options) {
+		log.Printf("  go-papertrail-cli %s\n", command)
+	}
+}
+
+// legacyEquivalentCommands returns the subcommands equivalent to the options of the invocation without subcommands
+func legacyEquivalentCommands(options *papertrail.Options) []string {
+	var commands []string
+	systemsIncluded := options.SystemWildcard != "*" && !options.DeleteOnlySearches &&
+		(!papertrail.ActionIsDelete(options.Action) || options.DeleteAllSystems)
+	if systemsIncluded {
+		for _, system := range legacySystems(options) {
+			switch {
+			case papertrail.ActionIsDelete(options.Action):
+				commands = append(commands, "systems delete --yes "+quoteArg(system))
+			case papertrail.ActionIsObtain(options.Action):
+				commands = append(commands, "systems get "+quoteArg(system))
+			case options.SystemType == "i" || options.SystemType == "ip-address":
+				commands = append(commands, "systems create --ip-address "+quoteArg(system))
+			case options.DestinationId != 0:
+				commands = append(commands, "systems create --hostname "+quoteArg(system)+
+					" --destination-id "+strconv.Itoa(options.DestinationId))
+			default:
+				commands = append(commands, "systems create --hostname "+quoteArg(system)+
+					" --destination-port "+strconv.Itoa(options.DestinationPort))
+			}
+		}
+	}
+	if options.DeleteOnlySystems {
+		return commands
+	}
+	group := quoteArg(options.GroupName)
+	search := quoteArg(options.Search)
+	switch {
+	case papertrail.ActionIsDelete(options.Action) && options.DeleteAllSearches:
+		commands = append(commands, "groups delete --yes "+group)
+	case papertrail.ActionIsDelete(options.Action):
+		commands = append(commands, "searches delete --group "+group+" --yes "+search)
+	case papertrail.ActionIsObtain(options.Action):
+		commands = append(commands, "events search --group "+group+" --search "+search+
+			" --start-date "+quoteArg(options.StartDate)+" --end-date "+quoteArg(options.EndDate)+
+			" --path "+quoteArg(options.Path))
+	default:
+		commands = append(commands, "groups create --system-wildcard "+quoteArg(options.SystemWildcard)+" "+group,
+			"searches create --group "+group+" --query "+quoteArg(options.Query)+" "+search)
+	}
+	return commands
+}
+
+// legacySystems returns the systems indicated in the invocation without subcommands, which are
+// the hostnames of the system wildcard or the ip address depending on the type of system
+func legacySystems(options *papertrail.Options) []string {
+	if options.SystemType == "i" || options.SystemType == "ip-address" {
+		return []string{options.IpAddress}
+	}
+	return strings.Split(options.SystemWildcard, ", ")
+}
+
+// quoteArg quotes the argument provided if it contains characters interpreted by the shell
+func quoteArg(arg string) string {
+	if len(arg) > 0 && strings.IndexAny(arg, " \t\"'*?[]$&|;<>()\\") == -1 {
+		return arg
+	}
+	return "'" + strings.Replace(arg, "'", `'"'"'`, -1) + "'"
+}
+
+// printFinalResultIfNotErrorsDetected prints the elements on which the actions have been
+// carried out, or the files where the events have been saved when the action is obtain
+func printFinalResultIfNotErrorsDetected(err error, actionName *string, papertrailActions []papertrail.Item) {
+	if err == nil && actionName != nil {
+		if !papertrail.ActionIsObtain(*actionName) {
+			if len(papertrailActions) > 0 {
+				log.Printf("%s actions have been carried out on the following elements\n", strings.Title(*actionName))
+				for _, item := range papertrailActions {
+					logItem(item)
+				}
+			}
+		} else {
+			for _, item := range papertrailActions {
+				if item.ItemType == "EventsSearch" {
+					log.Printf("%s saved in file %s", item.ItemType, item.ItemName)
+				}
+			}
+		}
+	}
+}
+
+// logItem logs an element on which an action has been carried out,
+// including the values of the fields changed if it has been updated
+func logItem(item papertrail.Item) {
+	if !item.Updated {
+		log.Printf("- %s with ID %d and name '%s'\n", item.ItemType, item.ID, item.ItemName)
+		return
+	}
+	log.Printf("- %s with ID %d and name '%s' updated\n", item.ItemType, item.ID, item.ItemName)
+	for _, change := range item.Changes {
+		log.Printf("    %s: '%s' -> '%s'\n", change.Field, change.Before, change.After)
+	}
+}
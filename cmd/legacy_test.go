@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLegacyAction_JsonResultWithPartialItems(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/groups.json":
+			w.Write([]byte(`[]`))
+		case r.Method == "POST" && r.URL.Path == "/api/v1/groups.json":
+			w.Write([]byte(`{"id": 7, "name": "group-test", "system_wildcard": "*"}`))
+		default:
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"message": "Query is invalid"}`))
+		}
+	}))
+	defer server.Close()
+	client := papertrail.NewClient(server.URL+"/api/v1/", "token", nil, "", log.New(ioutil.Discard, "", 0))
+	cmd := buildCLI(papertrail.NewApp(client))
+	var output bytes.Buffer
+	cmd.Writer = &output
+	err := cmd.RunContext(context.Background(), []string{"go-papertrail-cli", "--output", "json",
+		"-a", "c", "-g", "group-test", "-S", "errors", "-q", "severity:error", "-p", "1234"})
+	if err == nil {
+		t.Fatal("expected the error creating the search")
+	}
+	var result struct {
+		Action string
+		Items  []map[string]interface{}
+		Error  string
+	}
+	if err := json.Unmarshal(output.Bytes(), &result); err != nil {
+		t.Fatalf("invalid result document %q: %v", output.String(), err)
+	}
+	if result.Action != "create" || result.Error != err.Error() {
+		t.Errorf("unexpected result document: %+v", result)
+	}
+	if len(result.Items) != 1 || result.Items[0]["type"] != "Group" || result.Items[0]["id"] != float64(7) ||
+		result.Items[0]["created"] != true {
+		t.Errorf("unexpected items in result document: %+v", result.Items)
+	}
+}
+
+func TestLegacyAction_CreateKeepsValuesNotProvided(t *testing.T) {
+	var updates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/v1/groups.json":
+			w.Write([]byte(`[{"id": 7, "name": "group-test", "system_wildcard": "web-*"}]`))
+		case r.Method == "GET" && r.URL.Path == "/api/v1/searches.json":
+			w.Write([]byte(`[{"id": 9, "name": "errors", "query": "error", "group": {"id": 7, "name": "group-test"}}]`))
+		case r.Method == "PUT" && r.URL.Path == "/api/v1/searches/9.json":
+			updates = append(updates, r.URL.Path)
+			w.Write([]byte(`{"id": 9, "name": "errors", "query": "error OR fatal", "group": {"id": 7, "name": "group-test"}}`))
+		default:
+			updates = append(updates, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusUnprocessableEntity)
+		}
+	}))
+	defer server.Close()
+	client := papertrail.NewClient(server.URL+"/api/v1/", "token", nil, "", log.New(ioutil.Discard, "", 0))
+	cmd := buildCLI(papertrail.NewApp(client))
+	cmd.Writer = ioutil.Discard
+	err := cmd.RunContext(context.Background(), []string{"go-papertrail-cli", "-a", "c", "-g", "group-test", "-S", "errors", "-p", "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("expected the group and the search to keep their values, obtained the requests %v", updates)
+	}
+	err = cmd.RunContext(context.Background(), []string{"go-papertrail-cli", "-a", "c", "-g", "group-test", "-S", "errors",
+		"-q", "error OR fatal", "-p", "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 || updates[0] != "/api/v1/searches/9.json" {
+		t.Fatalf("expected only the query of the search to be updated, obtained the requests %v", updates)
+	}
+}
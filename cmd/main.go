@@ -1,29 +1,124 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"github.com/urfave/cli/v2"
 	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
 	"log"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 )
 
-// dateLayout define the layout to use with format
-// mm/dd/yyyy HH:MM:SSx
-const dateLayout = "01/02/2006 15:04:05"
-
 var version = "1.3.0"
 var date = time.Now().Format(time.RFC3339)
-var now = time.Now().UTC()
-var nowDate = now.Format(dateLayout)
-var nowDateLessEightHours = now.Add(-8 * time.Hour).Format(dateLayout)
 
 func main() {
-	cmd := buildCLI(&papertrail.App{})
-	if err := cmd.Run(os.Args); err != nil {
-		log.Fatal(err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cancelOnInterrupt(cancel)
+	cmd := buildCLI(papertrail.NewApp(papertrail.NewClientFromEnv()))
+	if err := cmd.RunContext(ctx, os.Args); err != nil {
+		log.Print(err)
+		os.Exit(exitCodeForError(err))
+	}
+}
+
+// Exit codes used to indicate the kind of error that stopped the execution
+const (
+	exitCodeError        = 1
+	exitCodeDrift        = 2
+	exitCodeUnauthorized = 3
+	exitCodeNotFound     = 4
+	exitCodeValidation   = 5
+	exitCodeRateLimited  = 6
+	exitCodeTimeout      = 124
+	exitCodeInterrupted  = 130
+)
+
+// exitCodeForError returns the exit code corresponding to the error provided
+func exitCodeForError(err error) int {
+	switch {
+	case errors.Is(err, errDriftDetected):
+		return exitCodeDrift
+	case errors.Is(err, papertrail.ErrUnauthorized):
+		return exitCodeUnauthorized
+	case errors.Is(err, papertrail.ErrNotFound):
+		return exitCodeNotFound
+	case errors.Is(err, papertrail.ErrValidation):
+		return exitCodeValidation
+	case errors.Is(err, papertrail.ErrRateLimited):
+		return exitCodeRateLimited
+	case errors.Is(err, context.DeadlineExceeded):
+		return exitCodeTimeout
+	case errors.Is(err, context.Canceled):
+		return exitCodeInterrupted
 	}
+	return exitCodeError
+}
+
+// cancelOnInterrupt cancels the execution context when an interrupt signal is received,
+// so the requests in progress are stopped. A second signal terminates the process immediately
+func cancelOnInterrupt(cancel context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		log.Printf("Interrupt received, stopping the requests in progress\n")
+		signal.Stop(signals)
+		cancel()
+	}()
+}
+
+// configureClient applies the global options related to the interaction
+// with papertrail's API to the client used by the app
+func configureClient(c *cli.Context, app *papertrail.App) {
+	if app.Client == nil {
+		app.Client = papertrail.NewClientFromEnv()
+	}
+	app.Client.StrictSchema = c.Bool("strict-schema")
+}
+
+// commandContext returns the context to be used by a command, applying
+// the timeout indicated through the timeout flag if any
+func commandContext(c *cli.Context) (context.Context, context.CancelFunc) {
+	if timeout := c.Duration("timeout"); timeout > 0 {
+		return context.WithTimeout(c.Context, timeout)
+	}
+	return context.WithCancel(c.Context)
+}
+
+// commandClient returns the client used by the commands, checking that
+// a token to interact with papertrail has been provided
+func commandClient(c *cli.Context, app *papertrail.App) (*papertrail.Client, error) {
+	configureClient(c, app)
+	if len(app.Client.Token) == 0 {
+		return nil, errors.New("Error getting value of PAPERTRAIL_API_TOKEN, " +
+			"it's necessary to define this variable with your papertrail's API token ")
+	}
+	return app.Client, nil
+}
+
+// requiredArg returns the only argument provided to a command, failing if
+// it has not been provided or if more than one argument has been provided
+func requiredArg(c *cli.Context, argName string) (string, error) {
+	if c.NArg() == 0 {
+		return "", errors.New("Error: it's necessary to provide the " + argName + " ")
+	}
+	return optionalArg(c)
+}
+
+// optionalArg returns the argument provided to a command if any, failing if more than one argument
+// has been provided, which usually happens when the flags are placed after the argument
+func optionalArg(c *cli.Context) (string, error) {
+	if c.NArg() > 1 {
+		return "", errors.New("Error: unexpected arguments " + strings.Join(c.Args().Tail(), " ") +
+			", the flags must be placed before the arguments ")
+	}
+	return c.Args().First(), nil
 }
 
 // buildCLI creates a CLI app
@@ -34,170 +129,48 @@ func buildCLI(app *papertrail.App) *cli.App {
 		Usage:    "interacts with papertrail through its api to perform both log collection actions and the creation/deletion of systems, groups and saved searches",
 		Version:  version,
 		Compiled: d,
-		UsageText: "go-papertrail-cli [--group-name <group-name>] [--system-wildcard <wildcard>] " +
-			"[--search <search-name>] [--query <query>] [--action <action>] " +
-			"[--delete-all-searches <delete-all-searches>] [--delete-only-searches <delete-only-searches>] " +
-			"[--delete-all-systems <delete-all-systems>]  [--delete-only-systems <delete-only-systems>]" +
-			"[--start-date <start-date>] [--end-date <end-date>] [--path <path>]",
+		UsageText: "go-papertrail-cli [--timeout <timeout>] [--strict-schema] <command> <subcommand> [options] [arguments]\n" +
+			"   go-papertrail-cli [legacy options] (deprecated)",
 		Authors: []*cli.Author{
 			{
 				Name:  "Xoan Mallon",
 				Email: "xoanmallon@gmail.com",
 			},
 		},
-		Flags: []cli.Flag{
-			&cli.StringFlag{
-				Name:    "group-name",
-				Usage:   "group defined or to be defined in papertrail",
-				Value:   "my-log-group",
-				Aliases: []string{"g"},
-			},
-
-			&cli.StringFlag{
-				Name:    "system-wildcard",
-				Usage:   "wildcard to be applied on the systems defined in papertrail",
-				Value:   "*",
-				Aliases: []string{"w"},
-			},
-
-			&cli.IntFlag{
-				Name:    "destination-port",
-				Usage:   "destination port for sending the logs of the indicated system/s",
-				Value:   0,
-				Aliases: []string{"p"},
-			},
-
-			&cli.IntFlag{
-				Name:    "destination-id",
-				Usage:   "destination id for sending the logs of the indicated system/s",
-				Value:   0,
-				Aliases: []string{"I"},
-			},
-
-			&cli.StringFlag{
-				Name:    "ip-address",
-				Usage:   "source ip address from sending the logs of the indicated system/s",
-				Value:   "",
-				Aliases: []string{"i"},
-			},
-
-			&cli.StringFlag{
-				Name:    "system-type",
-				Usage:   "Type of system, can be hostname or ip-address",
-				Value:   "hostname",
-				Aliases: []string{"t"},
-			},
-
-			&cli.StringFlag{
-				Name:    "search",
-				Usage:   "name of saved search to be performed on logs or to be created on a group",
-				Value:   "default search",
-				Aliases: []string{"S"},
-			},
-
-			&cli.StringFlag{
-				Name:    "query",
-				Usage:   "query to be performed on the group of logs or applied on the search to be created",
-				Value:   "*",
-				Aliases: []string{"q"},
-			},
-
-			&cli.StringFlag{
-				Name:    "action",
-				Usage:   "Action to be performed with the information provided for papertrail, possible values only c(create), o(obtain) or d(delete)",
-				Value:   "c",
-				Aliases: []string{"a"},
-			},
-
-			&cli.BoolFlag{
-				Name:    "delete-all-searches",
-				Usage:   "Indicates if all searches in a group or a specific search are going to be deleted",
-				Value:   false,
-				Aliases: []string{"d"},
-			},
-
-			&cli.BoolFlag{
-				Name:  "delete-only-searches",
-				Usage: "Indicates if only searches specified are going to be deleted",
-				Value: false,
+		Flags: append([]cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "maximum duration of the execution, e.g. 30s or 5m (0 means no timeout)",
+				Value: 0,
 			},
 
 			&cli.BoolFlag{
-				Name:    "delete-all-systems",
-				Usage:   "Indicates if all systems specified are going to be deleted",
-				Value:   true,
-				Aliases: []string{"D"},
-			},
-
-			&cli.BoolFlag{
-				Name:  "delete-only-systems",
-				Usage: "Indicates if only systems specified are going to be deleted",
+				Name:  "strict-schema",
+				Usage: "Reject the responses of papertrail's API containing fields unknown to the cli",
 				Value: false,
 			},
-
-			&cli.StringFlag{
-				Name:        "start-date",
-				Usage:       "filter only from a date specified ('mm/dd/yyyy hh:mm:ss' format UTC time)",
-				DefaultText: "$ACTUAL_DATE - 8hours",
-				Value:       nowDateLessEightHours,
-				Aliases:     []string{"s"},
-			},
-
-			&cli.StringFlag{
-				Name:        "end-date",
-				Usage:       "filter only until a date specified ('mm/dd/yyyy hh:mm:ss' format UTC time)",
-				DefaultText: "$ACTUAL_DATE",
-				Value:       nowDate,
-				Aliases:     []string{"e"},
-			},
-
-			&cli.StringFlag{
-				Name:    "path",
-				Usage:   "path where to store the logs",
-				Value:   "/tmp",
-				Aliases: []string{"P"},
-			},
-		},
-		Action: func(c *cli.Context) error {
-			// path, _ := filepath.Abs(c.String("path"))
-			logGroupName := c.String("group-name")
-			actionName := c.String("action")
-
-			papertrailActions, action, err := app.PapertrailActions(&papertrail.Options{
-				GroupName:          logGroupName,
-				SystemWildcard:     c.String("system-wildcard"),
-				DestinationPort:    c.Int("destination-port"),
-				DestinationId:      c.Int("destination-id"),
-				IpAddress:          c.String("ip-address"),
-				SystemType:         c.String("system-type"),
-				Search:             c.String("search"),
-				Query:              c.String("query"),
-				Action:             actionName,
-				DeleteAllSystems:   c.Bool("delete-all-systems"),
-				DeleteOnlySystems:  c.Bool("delete-only-systems"),
-				DeleteAllSearches:  c.Bool("delete-all-searches"),
-				DeleteOnlySearches: c.Bool("delete-only-searches"),
-				StartDate:          c.String("start-date"),
-				EndDate:            c.String("end-date"),
-				Path:               c.String("path"),
-			})
-			printFinalResultIfNotErrorsDetected(err, action, papertrailActions)
-			return err
+		}, legacyFlags()...),
+		Commands: []*cli.Command{
+			systemsCommand(app),
+			groupsCommand(app),
+			searchesCommand(app),
+			eventsCommand(app),
+			destinationsCommand(app),
+			applyCommand(app),
+			importCommand(app),
+			driftCommand(app),
 		},
+		Action: legacyAction(app),
 	}
 }
 
-func printFinalResultIfNotErrorsDetected(err error, actionName *string, papertrailActions []papertrail.Item) {
-	if err == nil && actionName != nil {
-		if !papertrail.ActionIsObtain(*actionName) {
-			if len(papertrailActions) > 0 {
-				log.Printf("%s actions have been carried out on the following elements\n", strings.Title(*actionName))
-				for _, item := range papertrailActions {
-					log.Printf("- %s with ID %d and name '%s'\n", item.ItemType, item.ID, item.ItemName)
-				}
-			}
-		} else {
-			log.Printf("%s saved in file %s", papertrailActions[0].ItemType, papertrailActions[0].ItemName)
+// printPartialResultIfErrorsDetected prints the elements processed before the execution
+// was stopped, for example the file with the events already fetched when it is interrupted
+func printPartialResultIfErrorsDetected(err error, papertrailActions []papertrail.Item) {
+	if err != nil && len(papertrailActions) > 0 {
+		log.Printf("Execution stopped before finishing, the following elements were processed\n")
+		for _, item := range papertrailActions {
+			logItem(item)
 		}
 	}
 }
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Formats available to render the elements obtained from papertrail
+const (
+	outputTable = "table"
+	outputJson  = "json"
+	outputYaml  = "yaml"
+	outputCsv   = "csv"
+)
+
+// resultOutputFlag is the flag used to select how the result of the actions performed in papertrail is rendered
+var resultOutputFlag = &cli.StringFlag{
+	Name:    "output",
+	Usage:   "format of the result of the actions performed, possible values text (logged) or json (written to the standard output)",
+	Value:   "text",
+	Aliases: []string{"o"},
+}
+
+// result is the structure used to render the result of the actions performed in papertrail,
+// including the elements processed before an error stopped the execution
+type result struct {
+	Action string            `json:"action,omitempty"`
+	Items  []papertrail.Item `json:"items"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// printResult writes the result of the actions performed in papertrail as a JSON document when
+// the json output has been selected. In text output the result is only logged by the caller
+func printResult(c *cli.Context, action *string, items []papertrail.Item, err error) error {
+	if errOutput := checkResultOutput(c); errOutput != nil {
+		return errOutput
+	}
+	if strings.ToLower(c.String("output")) == outputJson {
+		document := result{Items: items}
+		if document.Items == nil {
+			document.Items = []papertrail.Item{}
+		}
+		if action != nil {
+			document.Action = *action
+		}
+		if err != nil {
+			document.Error = err.Error()
+		}
+		return renderJson(c.App.Writer, document)
+	}
+	return nil
+}
+
+// checkResultOutput checks if a valid value is being used for the format of the result of the actions
+func checkResultOutput(c *cli.Context) error {
+	format := strings.ToLower(c.String("output"))
+	if format != "text" && format != outputJson {
+		return errors.New("Not valid option provided for output, the only valid values are: text or json ")
+	}
+	return nil
+}
+
+// column is the structure used to represent a field of an element
+// obtained from papertrail that can be rendered
+type column struct {
+
+	// Name of the column used to select it and as key in JSON and YAML
+	name string
+
+	// Header of the column in table and CSV formats
+	header string
+
+	// Function returning the value of the column for an element
+	value func(element interface{}) interface{}
+}
+
+// view is the structure used to render a kind of element obtained from papertrail
+type view struct {
+
+	// Columns available for the element
+	columns []column
+
+	// Names of the columns rendered when no columns are selected
+	defaultColumns []string
+}
+
+// outputFlags returns the flags used to select how the elements obtained from papertrail are rendered
+func outputFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Usage:   "format of the output, possible values table, json, yaml or csv",
+			Value:   outputTable,
+			Aliases: []string{"o"},
+		},
+		&cli.StringFlag{
+			Name:  "columns",
+			Usage: "comma separated list of the columns to be rendered, e.g. id,name",
+		},
+	}
+}
+
+// render writes the elements provided in the format indicated through the output flag. In JSON and
+// YAML formats the complete elements are rendered unless some columns are selected. When single is
+// true the only element provided is rendered as an object instead of as a list in those formats
+func render(c *cli.Context, v view, elements []interface{}, single bool) error {
+	format := strings.ToLower(c.String("output"))
+	columns, err := v.selectColumns(c.String("columns"), format)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case outputTable:
+		return renderTable(c.App.Writer, columns, elements)
+	case outputCsv:
+		return renderCsv(c.App.Writer, columns, elements)
+	case outputJson, outputYaml:
+		if columns != nil {
+			elements = selectedColumnsDocument(columns, elements)
+		}
+		var document interface{} = elements
+		if single && len(elements) == 1 {
+			document = elements[0]
+		}
+		if format == outputJson {
+			return renderJson(c.App.Writer, document)
+		}
+		return renderYaml(c.App.Writer, document)
+	}
+	return errors.New("Not valid option provided for output, the only valid values are: table, json, yaml or csv ")
+}
+
+// selectColumns returns the columns with the names provided separated by commas. If no names are
+// provided the default columns are returned, except in JSON and YAML formats where nil is returned
+func (v view) selectColumns(names string, format string) ([]column, error) {
+	if len(strings.TrimSpace(names)) == 0 {
+		if format == outputJson || format == outputYaml {
+			return nil, nil
+		}
+		names = strings.Join(v.defaultColumns, ",")
+	}
+	var columns []column
+	for _, name := range strings.Split(names, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, col := range v.columns {
+			if col.name == name {
+				columns = append(columns, col)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("Not valid column " + name + ", the only valid values are: " + v.columnNames())
+		}
+	}
+	return columns, nil
+}
+
+// columnNames returns the names of all the columns available separated by commas
+func (v view) columnNames() string {
+	var names []string
+	for _, col := range v.columns {
+		names = append(names, col.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// renderTable writes the elements as tab aligned columns preceded by a header
+func renderTable(w io.Writer, columns []column, elements []interface{}) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columnHeaders(columns), "\t"))
+	for _, element := range elements {
+		fmt.Fprintln(tw, strings.Join(columnValues(columns, element), "\t"))
+	}
+	return tw.Flush()
+}
+
+// renderCsv writes the elements as CSV records preceded by a header
+func renderCsv(w io.Writer, columns []column, elements []interface{}) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columnHeaders(columns)); err != nil {
+		return err
+	}
+	for _, element := range elements {
+		if err := cw.Write(columnValues(columns, element)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// renderJson writes the document provided as indented JSON
+func renderJson(w io.Writer, document interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(document)
+}
+
+// renderYaml writes the document provided as YAML. The document is converted to JSON
+// first, so the keys used and their order are the same in both formats
+func renderYaml(w io.Writer, document interface{}) error {
+	b, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+	var yamlDocument interface{} = &yaml.MapSlice{}
+	if _, isList := document.([]interface{}); isList {
+		yamlDocument = &[]yaml.MapSlice{}
+	}
+	if err := yaml.Unmarshal(b, yamlDocument); err != nil {
+		return err
+	}
+	b, err = yaml.Marshal(yamlDocument)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// selectedColumnsDocument returns the elements provided with only the columns
+// selected as JSON objects, keeping the order of the columns
+func selectedColumnsDocument(columns []column, elements []interface{}) []interface{} {
+	document := make([]interface{}, 0, len(elements))
+	for _, element := range elements {
+		var fields []string
+		for _, col := range columns {
+			key, _ := json.Marshal(col.name)
+			value, _ := json.Marshal(col.value(element))
+			fields = append(fields, string(key)+":"+string(value))
+		}
+		document = append(document, json.RawMessage("{"+strings.Join(fields, ",")+"}"))
+	}
+	return document
+}
+
+// columnHeaders returns the headers of the columns provided
+func columnHeaders(columns []column) []string {
+	var headers []string
+	for _, col := range columns {
+		headers = append(headers, col.header)
+	}
+	return headers
+}
+
+// columnValues returns the values of the columns provided for an element formatted as text
+func columnValues(columns []column, element interface{}) []string {
+	var values []string
+	for _, col := range columns {
+		values = append(values, formatValue(col.value(element)))
+	}
+	return values
+}
+
+// formatValue returns the value provided as text, using an empty string for the values not set
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+// confirmationInput is the input from which the confirmations of the user are read
+var confirmationInput io.Reader = os.Stdin
+
+// confirmAction asks the user to confirm an action that can't be undone, unless the
+// yes flag has been provided. An error is returned if the action is not confirmed
+func confirmAction(c *cli.Context, question string) error {
+	if c.Bool("yes") {
+		return nil
+	}
+	fmt.Fprintf(c.App.Writer, "%s [y/N]: ", question)
+	answer, err := bufio.NewReader(confirmationInput).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer != "y" && answer != "yes" {
+		return errors.New("Error: action cancelled, no changes were made ")
+	}
+	return nil
+}
+
+// yesFlag is the flag used to skip the confirmation of destructive actions
+var yesFlag = &cli.BoolFlag{
+	Name:    "yes",
+	Usage:   "do not ask for confirmation before deleting",
+	Value:   false,
+	Aliases: []string{"y"},
+}
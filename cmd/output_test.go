@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const systemsResponse = `[
+	{"id": 31, "name": "web-1", "hostname": "web-1.example.com", "ip_address": null,
+	 "last_event_at": "2020-05-04T16:44:53Z", "auto_delete": true, "syslog": {"hostname": "logs.papertrailapp.com", "port": 23633}},
+	{"id": 32, "name": "db, primary", "hostname": "db-1.example.com", "ip_address": "10.0.0.2", "syslog": {}}]`
+
+// runCLI runs the cli with the arguments provided against a server that always
+// returns the body provided, returning what has been written to the output
+func runCLI(t *testing.T, body string, args ...string) (string, error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	client := papertrail.NewClient(server.URL+"/api/v1/", "token", nil, "", log.New(ioutil.Discard, "", 0))
+	cmd := buildCLI(papertrail.NewApp(client))
+	var output bytes.Buffer
+	cmd.Writer = &output
+	err := cmd.RunContext(context.Background(), append([]string{"go-papertrail-cli"}, args...))
+	return output.String(), err
+}
+
+func TestSystemsList_Output(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name: "table",
+			args: []string{"systems", "list"},
+			expected: "ID  NAME         HOSTNAME           IP ADDRESS  LAST EVENT AT\n" +
+				"31  web-1        web-1.example.com              2020-05-04T16:44:53Z\n" +
+				"32  db, primary  db-1.example.com   10.0.0.2    \n",
+		},
+		{
+			name:     "table with columns",
+			args:     []string{"systems", "list", "--columns", "name,syslog_port"},
+			expected: "NAME         SYSLOG PORT\nweb-1        23633\ndb, primary  0\n",
+		},
+		{
+			name:     "csv",
+			args:     []string{"systems", "list", "--output", "csv", "--columns", "id,name"},
+			expected: "ID,NAME\n31,web-1\n32,\"db, primary\"\n",
+		},
+		{
+			name: "json with columns",
+			args: []string{"systems", "list", "-o", "json", "--columns", "name,id"},
+			expected: "[\n  {\n    \"name\": \"web-1\",\n    \"id\": 31\n  },\n" +
+				"  {\n    \"name\": \"db, primary\",\n    \"id\": 32\n  }\n]\n",
+		},
+		{
+			name:     "yaml with columns",
+			args:     []string{"systems", "list", "-o", "yaml", "--columns", "name,ip_address"},
+			expected: "- name: web-1\n  ip_address: null\n- name: db, primary\n  ip_address: 10.0.0.2\n",
+		},
+		{
+			name:     "get in yaml",
+			args:     []string{"systems", "get", "-o", "yaml", "--columns", "id", "31"},
+			expected: "id: 31\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body := systemsResponse
+			if test.args[1] == "get" {
+				body = `{"id": 31, "name": "web-1"}`
+			}
+			output, err := runCLI(t, body, test.args...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if output != test.expected {
+				t.Errorf("expected output:\n%q\nobtained:\n%q", test.expected, output)
+			}
+		})
+	}
+}
+
+func TestSystemsList_InvalidColumn(t *testing.T) {
+	_, err := runCLI(t, systemsResponse, "systems", "list", "--columns", "id,unknown")
+	if err == nil {
+		t.Fatal("expected an error for an unknown column")
+	}
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"strconv"
+)
+
+// searchGroupFlag is the flag used to indicate the group of the saved searches
+var searchGroupFlag = &cli.StringFlag{
+	Name:    "group",
+	Usage:   "id or name of the group of the saved search",
+	Aliases: []string{"g"},
+}
+
+// searchesCommand creates the command used to manage the saved searches defined in papertrail
+func searchesCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:  "searches",
+		Usage: "list, get, create, update or delete the saved searches",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "list all the saved searches, or only the ones of a group",
+				UsageText: "go-papertrail-cli searches list [--group <id|name>] [--output <format>] [--columns <columns>]",
+				Flags:     append([]cli.Flag{searchGroupFlag}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					groupId, err := groupIdFromFlag(ctx, c, client, "group")
+					if err != nil {
+						return err
+					}
+					searches, err := client.ListSearches(ctx)
+					if err != nil {
+						return err
+					}
+					var groupSearches []papertrail.SearchObject
+					for _, search := range searches {
+						if groupId == 0 || search.Group.ID == groupId {
+							groupSearches = append(groupSearches, search)
+						}
+					}
+					return printSearches(c, groupSearches, false)
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "show a saved search given its id or name",
+				UsageText: "go-papertrail-cli searches get [--group <id|name>] [--output <format>] [--columns <columns>] <id|name>",
+				Flags:     append([]cli.Flag{searchGroupFlag}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "search")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					search, err := findSearch(ctx, c, client, reference)
+					if err != nil {
+						return err
+					}
+					return printSearches(c, []papertrail.SearchObject{*search}, true)
+				},
+			},
+			{
+				Name:      "create",
+				Usage:     "create a saved search in a group",
+				UsageText: "go-papertrail-cli searches create --group <id|name> --query <query> <name>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:     "group",
+						Usage:    "id or name of the group where the saved search is created",
+						Aliases:  []string{"g"},
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "query",
+						Usage:    "query of the saved search",
+						Aliases:  []string{"q"},
+						Required: true,
+					},
+				}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					name, err := requiredArg(c, "search name")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					groupId, err := groupIdFromFlag(ctx, c, client, "group")
+					if err != nil {
+						return err
+					}
+					search, err := client.CreateSearch(ctx, name, c.String("query"), groupId)
+					if err != nil {
+						return err
+					}
+					return printSearches(c, []papertrail.SearchObject{*search}, true)
+				},
+			},
+			{
+				Name:  "update",
+				Usage: "change the name, query and/or group of a saved search given its id or name",
+				UsageText: "go-papertrail-cli searches update [--group <id|name>] [--name <name>] [--query <query>] " +
+					"[--to-group <id|name>] <id|name>",
+				Flags: append([]cli.Flag{
+					searchGroupFlag,
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "new name of the saved search",
+					},
+					&cli.StringFlag{
+						Name:    "query",
+						Usage:   "new query of the saved search",
+						Aliases: []string{"q"},
+					},
+					&cli.StringFlag{
+						Name:  "to-group",
+						Usage: "id or name of the group where the saved search is moved",
+					},
+				}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "search")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					search, err := findSearch(ctx, c, client, reference)
+					if err != nil {
+						return err
+					}
+					name := search.Name
+					if c.IsSet("name") {
+						name = c.String("name")
+					}
+					query := search.Query
+					if c.IsSet("query") {
+						query = c.String("query")
+					}
+					groupId := search.Group.ID
+					if c.IsSet("to-group") {
+						groupId, err = groupIdFromFlag(ctx, c, client, "to-group")
+						if err != nil {
+							return err
+						}
+					}
+					search, err = client.UpdateSearch(ctx, search.ID, name, query, groupId)
+					if err != nil {
+						return err
+					}
+					return printSearches(c, []papertrail.SearchObject{*search}, true)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a saved search given its id or name",
+				UsageText: "go-papertrail-cli searches delete [--group <id|name>] [--yes] <id|name>",
+				Flags:     []cli.Flag{searchGroupFlag, yesFlag},
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "search")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					search, err := findSearch(ctx, c, client, reference)
+					if err != nil {
+						return err
+					}
+					err = confirmAction(c, "Delete saved search '"+search.Name+"' with id "+strconv.Itoa(search.ID)+"?")
+					if err != nil {
+						return err
+					}
+					return client.DeleteSearch(ctx, search.ID)
+				},
+			},
+		},
+	}
+}
+
+// groupIdFromFlag returns the identifier of the group indicated through the flag provided,
+// or 0 if the flag has not been provided
+func groupIdFromFlag(ctx context.Context, c *cli.Context, client *papertrail.Client, flagName string) (int, error) {
+	group, err := groupFromFlag(ctx, c, client, flagName)
+	if err != nil || group == nil {
+		return 0, err
+	}
+	return group.ID, nil
+}
+
+// groupFromFlag returns the group indicated through the flag provided, or nil if the flag has not been provided
+func groupFromFlag(ctx context.Context, c *cli.Context, client *papertrail.Client, flagName string) (*papertrail.GroupObject, error) {
+	reference := c.String(flagName)
+	if len(reference) == 0 {
+		return nil, nil
+	}
+	return client.FindGroup(ctx, reference)
+}
+
+// findSearch returns the saved search with the identifier or name provided, looking
+// up the name only in the group indicated through the group flag if it has been provided
+func findSearch(ctx context.Context, c *cli.Context, client *papertrail.Client, reference string) (*papertrail.SearchObject, error) {
+	groupId, err := groupIdFromFlag(ctx, c, client, "group")
+	if err != nil {
+		return nil, err
+	}
+	return client.FindSearch(ctx, reference, groupId)
+}
+
+// searchesView is the view used to render the saved searches
+var searchesView = view{
+	columns: []column{
+		{name: "id", header: "ID", value: func(e interface{}) interface{} { return e.(papertrail.SearchObject).ID }},
+		{name: "name", header: "NAME", value: func(e interface{}) interface{} { return e.(papertrail.SearchObject).Name }},
+		{name: "query", header: "QUERY", value: func(e interface{}) interface{} { return e.(papertrail.SearchObject).Query }},
+		{name: "group_id", header: "GROUP ID", value: func(e interface{}) interface{} { return e.(papertrail.SearchObject).Group.ID }},
+		{name: "group_name", header: "GROUP NAME", value: func(e interface{}) interface{} { return e.(papertrail.SearchObject).Group.Name }},
+	},
+	defaultColumns: []string{"id", "name", "query", "group_id", "group_name"},
+}
+
+// printSearches renders the saved searches provided in the output format selected
+func printSearches(c *cli.Context, searches []papertrail.SearchObject, single bool) error {
+	elements := make([]interface{}, 0, len(searches))
+	for _, search := range searches {
+		elements = append(elements, search)
+	}
+	return render(c, searchesView, elements, single)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"github.com/urfave/cli/v2"
+	"github.com/xoanmm/go-papertrail-cli/pkg/papertrail"
+	"strconv"
+)
+
+// systemsCommand creates the command used to manage the systems sending logs to papertrail
+func systemsCommand(app *papertrail.App) *cli.Command {
+	return &cli.Command{
+		Name:  "systems",
+		Usage: "list, get, create, update or delete the systems sending logs to papertrail",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "list",
+				Usage:     "list all the systems",
+				UsageText: "go-papertrail-cli systems list [--output <format>] [--columns <columns>]",
+				Flags:     outputFlags(),
+				Action: func(c *cli.Context) error {
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					systems, err := client.ListSystems(ctx)
+					if err != nil {
+						return err
+					}
+					return printSystems(c, systems, false)
+				},
+			},
+			{
+				Name:      "get",
+				Usage:     "show a system given its id, name, hostname or ip address",
+				UsageText: "go-papertrail-cli systems get [--output <format>] [--columns <columns>] <id|name|hostname|ip-address>",
+				Flags:     outputFlags(),
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "system")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					system, err := client.FindSystem(ctx, reference)
+					if err != nil {
+						return err
+					}
+					return printSystems(c, []papertrail.System{*system}, true)
+				},
+			},
+			{
+				Name:  "create",
+				Usage: "create a system based in hostname or in ip address, by default named as the hostname or ip address",
+				UsageText: "go-papertrail-cli systems create (--hostname <hostname> (--destination-port <port> | --destination-id <id>) " +
+					"| --ip-address <ip-address>) [name]",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "hostname",
+						Usage: "hostname of the system sending the logs",
+					},
+					&cli.StringFlag{
+						Name:  "ip-address",
+						Usage: "source ip address of the system sending the logs",
+					},
+					&cli.IntFlag{
+						Name:  "destination-port",
+						Usage: "destination port for sending the logs of the system based in hostname",
+					},
+					&cli.IntFlag{
+						Name:  "destination-id",
+						Usage: "destination id for sending the logs of the system based in hostname",
+					},
+				}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					name, err := optionalArg(c)
+					if err != nil {
+						return err
+					}
+					hostname := c.String("hostname")
+					ipAddress := c.String("ip-address")
+					if len(hostname) > 0 == (len(ipAddress) > 0) {
+						return errors.New("Error: it's necessary to provide either hostname or ip address ")
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					var system *papertrail.System
+					if len(hostname) > 0 {
+						system, err = client.CreateSystemBasedInHostname(ctx, name, hostname,
+							c.Int("destination-port"), c.Int("destination-id"))
+					} else {
+						system, err = client.CreateSystemBasedInIPAddress(ctx, name, ipAddress)
+					}
+					if err != nil {
+						return err
+					}
+					return printSystems(c, []papertrail.System{*system}, true)
+				},
+			},
+			{
+				Name: "update",
+				Usage: "change the name, the hostname, the destination and/or the auto delete option of a system given " +
+					"its id, name, hostname or ip address, keeping its id and its events",
+				UsageText: "go-papertrail-cli systems update [--name <name>] [--hostname <hostname>] " +
+					"[--destination-port <port> | --destination-id <id>] [--auto-delete=true|false] <id|name|hostname|ip-address>",
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "new name of the system",
+					},
+					&cli.StringFlag{
+						Name:  "hostname",
+						Usage: "new hostname of the system sending the logs",
+					},
+					&cli.IntFlag{
+						Name:  "destination-port",
+						Usage: "new destination port for sending the logs of the system",
+					},
+					&cli.IntFlag{
+						Name:  "destination-id",
+						Usage: "new destination id for sending the logs of the system",
+					},
+					&cli.BoolFlag{
+						Name:  "auto-delete",
+						Usage: "whether the system is deleted automatically when it stops sending logs",
+					},
+				}, outputFlags()...),
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "system")
+					if err != nil {
+						return err
+					}
+					update := papertrail.SystemUpdate{
+						Name:            c.String("name"),
+						Hostname:        c.String("hostname"),
+						DestinationPort: c.Int("destination-port"),
+						DestinationID:   c.Int("destination-id"),
+					}
+					if c.IsSet("auto-delete") {
+						autoDelete := c.Bool("auto-delete")
+						update.AutoDelete = &autoDelete
+					}
+					if update == (papertrail.SystemUpdate{}) {
+						return errors.New("Error: it's necessary to provide at least one of name, hostname, destination port, " +
+							"destination id or auto delete ")
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					system, err := client.FindSystem(ctx, reference)
+					if err != nil {
+						return err
+					}
+					system, err = client.UpdateSystem(ctx, system.ID, update)
+					if err != nil {
+						return err
+					}
+					return printSystems(c, []papertrail.System{*system}, true)
+				},
+			},
+			{
+				Name:      "delete",
+				Usage:     "delete a system given its id, name, hostname or ip address",
+				UsageText: "go-papertrail-cli systems delete [--yes] <id|name|hostname|ip-address>",
+				Flags:     []cli.Flag{yesFlag},
+				Action: func(c *cli.Context) error {
+					reference, err := requiredArg(c, "system")
+					if err != nil {
+						return err
+					}
+					client, err := commandClient(c, app)
+					if err != nil {
+						return err
+					}
+					ctx, cancel := commandContext(c)
+					defer cancel()
+					system, err := client.FindSystem(ctx, reference)
+					if err != nil {
+						return err
+					}
+					err = confirmAction(c, "Delete system '"+system.Name+"' with id "+strconv.FormatInt(system.ID, 10)+"?")
+					if err != nil {
+						return err
+					}
+					return client.DeleteSystem(ctx, system.ID)
+				},
+			},
+		},
+	}
+}
+
+// systemsView is the view used to render the systems
+var systemsView = view{
+	columns: []column{
+		{name: "id", header: "ID", value: func(e interface{}) interface{} { return e.(papertrail.System).ID }},
+		{name: "name", header: "NAME", value: func(e interface{}) interface{} { return e.(papertrail.System).Name }},
+		{name: "hostname", header: "HOSTNAME", value: func(e interface{}) interface{} { return e.(papertrail.System).Hostname }},
+		{name: "ip_address", header: "IP ADDRESS", value: func(e interface{}) interface{} { return e.(papertrail.System).IPAddress }},
+		{name: "last_event_at", header: "LAST EVENT AT", value: func(e interface{}) interface{} { return e.(papertrail.System).LastEventAt }},
+		{name: "auto_delete", header: "AUTO DELETE", value: func(e interface{}) interface{} { return e.(papertrail.System).AutoDelete }},
+		{name: "syslog_hostname", header: "SYSLOG HOSTNAME", value: func(e interface{}) interface{} { return e.(papertrail.System).Syslog.Hostname }},
+		{name: "syslog_port", header: "SYSLOG PORT", value: func(e interface{}) interface{} { return e.(papertrail.System).Syslog.Port }},
+	},
+	defaultColumns: []string{"id", "name", "hostname", "ip_address", "last_event_at"},
+}
+
+// printSystems renders the systems provided in the output format selected
+func printSystems(c *cli.Context, systems []papertrail.System, single bool) error {
+	elements := make([]interface{}, 0, len(systems))
+	for _, system := range systems {
+		elements = append(elements, system)
+	}
+	return render(c, systemsView, elements, single)
+}
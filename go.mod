@@ -4,5 +4,7 @@ go 1.14
 
 require (
 	github.com/joho/godotenv v1.3.0
+	github.com/klauspost/compress v1.11.13
 	github.com/urfave/cli/v2 v2.2.0
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,6 +1,7 @@
 package papertrail
 
 import (
+	"context"
 	"strings"
 )
 
@@ -11,24 +12,44 @@ const papertrailTokenName = "X-Papertrail-Token"
 const papertrailApiBaseUrl = "https://papertrailapp.com/api/v1/"
 
 // App contains the necessary information to interact with papertrail
-type App struct{}
+type App struct {
+
+	// Client used to interact with papertrail's API, when it is not provided
+	// a client is created from the PAPERTRAIL_API_TOKEN environment variable
+	Client *Client
+}
+
+// NewApp allows to create a App type struct providing all the information for it
+func NewApp(client *Client) *App {
+	return &App{Client: client}
+}
+
+// client returns the client configured in the app or a new one based in the environment
+func (a *App) client() *Client {
+	if a.Client != nil {
+		return a.Client
+	}
+	return NewClientFromEnv()
+}
 
 // PapertrailActions interacts with papertrails' API to do the necessary actions
-// in function of the values provided for the options
-func (a *App) PapertrailActions(options *Options) ([]Item, *string, error) {
+// in function of the values provided for the options. The requests in progress are
+// cancelled when the context provided is done
+func (a *App) PapertrailActions(ctx context.Context, options *Options) ([]Item, *string, error) {
 	var err error
-	printActionsToDoMessage(*options)
+	client := a.client()
+	client.printActionsToDoMessage(*options)
 	startDateUnix, endDateUnix, err := cnvStDateEndDateToUnixTime(options.StartDate, options.EndDate)
 	if err != nil {
 		return nil, nil, err
 	}
-	err = checkNecessaryConditions(options.Action, options.SystemType, options.IpAddress, options.DestinationId,
-		options.DestinationPort, startDateUnix, endDateUnix)
+	err = checkNecessaryConditions(client.Token, options.Action, options.SystemType, options.IpAddress,
+		options.DestinationId, options.DestinationPort, startDateUnix, endDateUnix)
 	if err != nil {
 		return nil, nil, err
 	}
 	actionName := getNameOfAction(options.Action)
-	createdOrDeletedItems, action, err := getItems(*options, actionName, startDateUnix, endDateUnix)
+	createdOrDeletedItems, action, err := client.getItems(ctx, *options, actionName, startDateUnix, endDateUnix)
 	if err != nil {
 		if createdOrDeletedItems != nil {
 			return *createdOrDeletedItems, action, err
@@ -40,20 +61,22 @@ func (a *App) PapertrailActions(options *Options) ([]Item, *string, error) {
 
 // getItems collects specific group and/or search details and adds
 // them to the list of created items if they have been created
-func getItems(options Options, actionName string, startDate int64, endDate int64) (*[]Item, *string, error) {
+func (c *Client) getItems(ctx context.Context, options Options, actionName string, startDate int64, endDate int64) (*[]Item, *string, error) {
 	var papertrailCreatedOrRemovedItems []Item
 	var err error
 	if !options.DeleteOnlySearches {
-		papertrailCreatedOrRemovedItems, err = addSystemElements(options.SystemType, options.SystemWildcard,
+		papertrailCreatedOrRemovedItems, err = c.addSystemElements(ctx, options.SystemType, options.SystemWildcard,
 			options.DestinationPort, options.DestinationId, options.IpAddress, actionName, options.DeleteAllSystems)
 		if err != nil {
-			return nil, nil, err
+			return &papertrailCreatedOrRemovedItems, &actionName, err
 		}
 	}
 	if !options.DeleteOnlySystems {
-		groupAndSearchItems, err := addGroupsAndSearches(options.GroupName, options.SystemWildcard, actionName,
-			options.Search, options.Query, options.DeleteAllSearches, options.DeleteAllSystems, startDate, endDate, options.Path)
+		groupAndSearchItems, err := c.addGroupsAndSearches(ctx, options.GroupName, options.SystemWildcard, actionName,
+			options.Search, options.Query, options.DeleteAllSearches, options.DeleteAllSystems, startDate, endDate, options.Path,
+			options.EventsFormatter, options.SystemWildcardProvided, options.QueryProvided, options.NoUpdate)
 		if err != nil {
+			papertrailCreatedOrRemovedItems = addItemsToCreatedOrDeletedItems(groupAndSearchItems, papertrailCreatedOrRemovedItems)
 			return &papertrailCreatedOrRemovedItems, &actionName, err
 		}
 		papertrailCreatedOrRemovedItems = addItemsToCreatedOrDeletedItems(groupAndSearchItems, papertrailCreatedOrRemovedItems)
@@ -63,31 +86,37 @@ func getItems(options Options, actionName string, startDate int64, endDate int64
 
 // addGroupsAndSearches collects the information of items such as
 // groups and papertrail searches created or deleted during execution
-func addGroupsAndSearches(groupName string, systemWildcard string, actionName string, searchName string,
-	searchQuery string, deleteAll bool, deleteAllSystems bool, startDate int64, endDate int64, path string) ([]Item, error) {
+func (c *Client) addGroupsAndSearches(ctx context.Context, groupName string, systemWildcard string, actionName string, searchName string,
+	searchQuery string, deleteAll bool, deleteAllSystems bool, startDate int64, endDate int64, path string,
+	formatter EventsFormatter, ensureWildcard bool, ensureQuery bool, noUpdate bool) ([]Item, error) {
 	var papertrailCreatedItems []Item
 	if ActionIsDelete(actionName) {
 		var err error
-		papertrailCreatedItems, err = addGroupAndSearchesDeleted(deleteAll, groupName, actionName,
+		papertrailCreatedItems, err = c.addGroupAndSearchesDeleted(ctx, deleteAll, groupName, actionName,
 			systemWildcard, searchName, searchQuery, deleteAllSystems)
 		if err != nil {
 			return nil, err
 		}
 	} else {
-		groupItem, err := doPapertrailGroupNecessaryActions(groupName, actionName, systemWildcard, deleteAllSystems)
+		groupItem, err := c.doPapertrailGroupNecessaryActions(ctx, groupName, actionName, systemWildcard, deleteAllSystems,
+			ensureWildcard, noUpdate)
 		if err != nil {
 			return nil, err
 		}
 		papertrailCreatedItems = addItemToCreatedOrDeletedItems(*groupItem, papertrailCreatedItems)
-		searchItem, err := doPapertrailSearchNecessaryActions(searchName, searchQuery, groupItem.ID, actionName)
+		searchItem, err := c.doPapertrailSearchNecessaryActions(ctx, searchName, searchQuery, groupItem.ID, actionName,
+			ensureQuery, noUpdate)
 		if err != nil {
-			return nil, err
+			return papertrailCreatedItems, err
 		}
 		if ActionIsObtain(actionName) {
-			eventSearchItem, err := doPapertrailEventsSearch(groupName, groupItem.ID, searchName,
-				searchQuery, startDate, endDate, path)
+			eventSearchItem, err := c.doPapertrailEventsSearch(ctx, groupName, groupItem.ID, nil, searchName,
+				searchQuery, startDate, endDate, EventsExport{Path: path, Formatter: formatter, Mode: FileModeOverwrite})
 			if err != nil {
-				return nil, err
+				if eventSearchItem != nil {
+					return addItemToCreatedOrDeletedItems(*eventSearchItem, papertrailCreatedItems), err
+				}
+				return papertrailCreatedItems, err
 			}
 			papertrailCreatedItems = addItemToCreatedOrDeletedItems(*eventSearchItem, papertrailCreatedItems)
 		}
@@ -98,11 +127,11 @@ func addGroupsAndSearches(groupName string, systemWildcard string, actionName st
 
 // addGroupAndSearchesDeleted collects the information of items such as
 // groups and papertrail searches deleted during execution
-func addGroupAndSearchesDeleted(deleteAllSearchs bool, groupName string, actionName string,
+func (c *Client) addGroupAndSearchesDeleted(ctx context.Context, deleteAllSearchs bool, groupName string, actionName string,
 	systemWildcard string, searchName string, searchQuery string, deleteAllSystems bool) ([]Item, error) {
 	var papertrailDeletedItems []Item
 	if deleteAllSearchs {
-		groupItem, err := doPapertrailGroupNecessaryActions(groupName, actionName, systemWildcard, deleteAllSystems)
+		groupItem, err := c.doPapertrailGroupNecessaryActions(ctx, groupName, actionName, systemWildcard, deleteAllSystems, false, false)
 		if err != nil {
 			return nil, err
 		}
@@ -110,12 +139,12 @@ func addGroupAndSearchesDeleted(deleteAllSearchs bool, groupName string, actionN
 			papertrailDeletedItems = addItemToCreatedOrDeletedItems(*groupItem, papertrailDeletedItems)
 		}
 	} else {
-		groupItem, err := doPapertrailGroupNecessaryActions(groupName, "obtain", systemWildcard, deleteAllSystems)
+		groupItem, err := c.doPapertrailGroupNecessaryActions(ctx, groupName, "obtain", systemWildcard, deleteAllSystems, false, false)
 		if err != nil {
 			return nil, err
 		}
 		if groupItem != nil {
-			searchItem, err := doPapertrailSearchNecessaryActions(searchName, searchQuery, groupItem.ID, actionName)
+			searchItem, err := c.doPapertrailSearchNecessaryActions(ctx, searchName, searchQuery, groupItem.ID, actionName, false, false)
 			if err != nil {
 				return nil, err
 			}
@@ -126,25 +155,26 @@ func addGroupAndSearchesDeleted(deleteAllSearchs bool, groupName string, actionN
 }
 
 // addSystemElements collects specific system/s details and adds
-// them to the list of created/deleted items if they have been created or deleted
-func addSystemElements(systemType string, systemWildcard string, destinationPort int,
+// them to the list of created/deleted items if they have been created or deleted.
+// If an error occurs, the items processed until that moment are returned along with it
+func (c *Client) addSystemElements(ctx context.Context, systemType string, systemWildcard string, destinationPort int,
 	destinationId int, ipAddress string, actionName string, deleteAllSystems bool) ([]Item, error) {
 	var papertrailCreatedItems []Item
 	if systemWildcard != "*" && checkConditionsForDeleteAllSystems(actionName, deleteAllSystems) {
 		systems := strings.Split(systemWildcard, ", ")
 		for _, item := range systems {
 			if systemTypeIsHostname(systemType) {
-				systemItem, err := getSystemInPapertrailBasedInHostname(item, destinationPort, destinationId, actionName)
+				systemItem, err := c.getSystemInPapertrailBasedInHostname(ctx, item, destinationPort, destinationId, actionName)
 				if err != nil {
-					return nil, err
+					return papertrailCreatedItems, err
 				}
 				if systemItem != nil {
 					papertrailCreatedItems = addItemToCreatedOrDeletedItems(*systemItem, papertrailCreatedItems)
 				}
 			} else if systemTypeIsIpAddress(systemType) {
-				systemItem, err := getSystemInPapertrailBasedInAddressIp(ipAddress, actionName)
+				systemItem, err := c.getSystemInPapertrailBasedInAddressIp(ctx, ipAddress, actionName)
 				if err != nil {
-					return nil, err
+					return papertrailCreatedItems, err
 				}
 				if systemItem != nil {
 					papertrailCreatedItems = addItemToCreatedOrDeletedItems(*systemItem, papertrailCreatedItems)
@@ -157,12 +187,12 @@ func addSystemElements(systemType string, systemWildcard string, destinationPort
 
 // printActionsToDoMessage prints a message at the beginning of the execution
 // with the value of the parameters needed to perform the necessary action
-func printActionsToDoMessage(options Options) {
+func (c *Client) printActionsToDoMessage(options Options) {
 	if ActionIsDelete(options.Action) {
-		printMessageActionDelete(options)
+		c.printMessageActionDelete(options)
 	} else if ActionIsObtain(options.Action) {
-		printMessageActionObtain(options)
+		c.printMessageActionObtain(options)
 	} else {
-		printMessageActionCreate(options)
+		c.printMessageActionCreate(options)
 	}
 }
@@ -1,6 +1,7 @@
 package papertrail
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/joho/godotenv"
@@ -9,6 +10,7 @@ import (
 
 	"log"
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -22,6 +24,7 @@ var nowDateLessEightHours = now.Add(-8 * time.Hour).Format(dateLayout)
 var papertrailApiToken string
 var destinationDefaultId int
 var destinationDefaultPort int
+var integrationEnvLoaded bool
 
 // setupEnv checks if there is an `.env' file where a series of variables
 // used to perform the integration tests are defined
@@ -38,42 +41,52 @@ func TestMain(m *testing.M) {
 	// load .env file
 	err := setupEnv()
 	if err != nil {
-		log.Fatal(err)
-	}
-	papertrailApiToken = os.Getenv("PAPERTRAIL_API_TOKEN")
-	destinationDefaultId, err = strconv.Atoi(os.Getenv("DESTINATION_DEFAULT_ID"))
-	if err != nil {
-		log.Fatal(err)
-	}
-	destinationDefaultPort, err = strconv.Atoi(os.Getenv("DESTINATION_DEFAULT_PORT"))
-	if err != nil {
-		log.Fatal(err)
+		log.Printf("%v, integration tests against papertrail will be skipped\n", err)
+	} else {
+		integrationEnvLoaded = true
+		papertrailApiToken = os.Getenv("PAPERTRAIL_API_TOKEN")
+		destinationDefaultId, err = strconv.Atoi(os.Getenv("DESTINATION_DEFAULT_ID"))
+		if err != nil {
+			log.Fatal(err)
+		}
+		destinationDefaultPort, err = strconv.Atoi(os.Getenv("DESTINATION_DEFAULT_PORT"))
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 	code := m.Run()
 	os.Exit(code)
 }
 
+// requireIntegrationEnv skips the test if the `.env' file with the variables
+// needed to interact with papertrail has not been loaded
+func requireIntegrationEnv(t *testing.T) {
+	if !integrationEnvLoaded {
+		t.Skip("no .env file available to interact with papertrail")
+	}
+}
+
 func TestApp_PapertrailActionsNoProvidedToken(t *testing.T) {
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	os.Setenv("PAPERTRAIL_API_TOKEN", "")
 	app := App{}
-	_, _, err := app.PapertrailActions(&Options{
-		"group-name",
-		"*",
-		0,
-		7777,
-		"",
-		"hostname",
-		"default search",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
+	_, _, err := app.PapertrailActions(context.Background(), &Options{
+		GroupName:          "group-name",
+		SystemWildcard:     "*",
+		DestinationPort:    0,
+		DestinationId:      7777,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
 	})
 	expectedError := errors.New("Error getting value of PAPERTRAIL_API_TOKEN, it's necessary to define this variable with your papertrail's API token ")
 	if err.Error() != expectedError.Error() {
@@ -88,23 +101,23 @@ func TestApp_PapertrailActionsInvalidHostConfiguration(t *testing.T) {
 	if errT != nil {
 		log.Fatal(errT)
 	}
-	_, _, err := app.PapertrailActions(&Options{
-		"group-name",
-		"*",
-		0,
-		0,
-		"",
-		"hostname",
-		"default search",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
+	_, _, err := app.PapertrailActions(context.Background(), &Options{
+		GroupName:          "group-name",
+		SystemWildcard:     "*",
+		DestinationPort:    0,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
 	})
 	expectedError := errors.New("It's necessary provide a value distinct from default (0) to destination id or destination port ")
 	if err.Error() != expectedError.Error() {
@@ -119,23 +132,23 @@ func TestApp_PapertrailActionsInvalidActionConfiguration(t *testing.T) {
 	if errT != nil {
 		log.Fatal(errT)
 	}
-	_, _, err := app.PapertrailActions(&Options{
-		"group-name",
-		"*",
-		0,
-		0,
-		"",
-		"hostname",
-		"default search",
-		"*",
-		"ddd",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
+	_, _, err := app.PapertrailActions(context.Background(), &Options{
+		GroupName:          "group-name",
+		SystemWildcard:     "*",
+		DestinationPort:    0,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search",
+		Query:              "*",
+		Action:             "ddd",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
 	})
 	expectedError := errors.New("Not valid option provided for action to perform, the only valid values are: \n" +
 		"\t'c' or 'create': create new system/s, group and/or search\n" +
@@ -153,23 +166,23 @@ func TestApp_PapertrailActionsInvalidSystemProvided(t *testing.T) {
 	if errT != nil {
 		log.Fatal(errT)
 	}
-	_, _, err := app.PapertrailActions(&Options{
-		"group-name",
-		"*",
-		0,
-		7777,
-		"",
-		"hostnameee",
-		"default search",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
+	_, _, err := app.PapertrailActions(context.Background(), &Options{
+		GroupName:          "group-name",
+		SystemWildcard:     "*",
+		DestinationPort:    0,
+		DestinationId:      7777,
+		IpAddress:          "",
+		SystemType:         "hostnameee",
+		Search:             "default search",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
 	})
 	expectedError := errors.New("Not valid option provided for system, the only valid values are: \n" +
 		"\t'h' or 'hostname': system based in hostname\n" +
@@ -186,23 +199,23 @@ func TestApp_PapertrailActionsTwoSystemConfigurationProvided(t *testing.T) {
 	if errT != nil {
 		log.Fatal(errT)
 	}
-	_, _, err := app.PapertrailActions(&Options{
-		"group-name",
-		"*",
-		7777,
-		7777,
-		"",
-		"hostname",
-		"default search",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
+	_, _, err := app.PapertrailActions(context.Background(), &Options{
+		GroupName:          "group-name",
+		SystemWildcard:     "*",
+		DestinationPort:    7777,
+		DestinationId:      7777,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
 	})
 	expectedError := errors.New("If the system is a hostname-type system, only destination " +
 		"id or destination port can be specified\n")
@@ -218,23 +231,23 @@ func TestApp_PapertrailActionsTwoSystemConfigurationIncorrect(t *testing.T) {
 	if errT != nil {
 		log.Fatal(errT)
 	}
-	_, _, err := app.PapertrailActions(&Options{
-		"group-name",
-		"*",
-		0,
-		0,
-		"",
-		"hostname",
-		"default search",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
+	_, _, err := app.PapertrailActions(context.Background(), &Options{
+		GroupName:          "group-name",
+		SystemWildcard:     "*",
+		DestinationPort:    0,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
 	})
 	expectedError := errors.New("It's necessary provide a value distinct from default (0) to " +
 		"destination id or destination port ")
@@ -250,23 +263,23 @@ func TestApp_PapertrailActionsInvalidIpAddressConfiguration(t *testing.T) {
 	if errT != nil {
 		log.Fatal(errT)
 	}
-	_, _, err := app.PapertrailActions(&Options{
-		"group-name",
-		"*",
-		0,
-		0,
-		"11111111",
-		"ip-address",
-		"default search",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
+	_, _, err := app.PapertrailActions(context.Background(), &Options{
+		GroupName:          "group-name",
+		SystemWildcard:     "*",
+		DestinationPort:    0,
+		DestinationId:      0,
+		IpAddress:          "11111111",
+		SystemType:         "ip-address",
+		Search:             "default search",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
 	})
 	expectedError := errors.New("The IP Address provided, 11111111 it's not a valid IP Address ")
 	if err.Error() != expectedError.Error() {
@@ -287,7 +300,7 @@ func EqualSlices(a, b []Item) bool {
 		return false
 	}
 	for i, v := range a {
-		if v != b[i] {
+		if !reflect.DeepEqual(v, b[i]) {
 			return false
 		}
 	}
@@ -299,7 +312,7 @@ func testDeleteOnlySystemsHostnameDestinationPort(t *testing.T, options Options,
 	options.DeleteAllSearches = true
 	options.Action = "delete"
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -321,7 +334,7 @@ func testDeleteSystemsHostnameDestinationPortGroupAndAllSearchs(t *testing.T, op
 	options.DeleteAllSearches = true
 	options.Action = "delete"
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -345,7 +358,7 @@ func testDeleteSystemsHostnameDestinationPortGroupAndSearchsOnlySearchs(t *testi
 	options.Action = "delete"
 	options.DeleteAllSystems = false
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -360,27 +373,28 @@ func testDeleteSystemsHostnameDestinationPortGroupAndSearchsOnlySearchs(t *testi
 }
 
 func TestApp_PapertrailActionsCreateSystemsHostnameDestinationPortGroupAndSearchs(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	defer testDeleteSystemsHostnameDestinationPortGroupAndAllSearchs(t, *options, createdItems)
 	defer testDeleteSystemsHostnameDestinationPortGroupAndSearchsOnlySearchs(t, *options, createdItems)
 	expectedCreatedSystem1 := NewItem(0, "System", "15.21.10.1", true, false)
@@ -399,27 +413,28 @@ func TestApp_PapertrailActionsCreateSystemsHostnameDestinationPortGroupAndSearch
 }
 
 func TestApp_PapertrailActionsCreateSystemsHostnameDestinationIdGroupAndSearchs(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		0,
-		destinationDefaultId,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		true,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    0,
+		DestinationId:      destinationDefaultId,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  true,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	defer testDeleteSystemsHostnameDestinationPortGroupAndAllSearchs(t, *options, createdItems)
 	expectedCreatedSystem1 := NewItem(0, "System", "15.21.10.1", true, false)
 	expectedCreatedSystem2 := NewItem(0, "System", "3.2.13.90", true, false)
@@ -441,7 +456,7 @@ func testDeleteOnlySystemIpAddressDestinationPort(t *testing.T, options Options,
 	options.Action = "delete"
 	options.DeleteAllSystems = true
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -456,27 +471,28 @@ func testDeleteOnlySystemIpAddressDestinationPort(t *testing.T, options Options,
 }
 
 func TestApp_PapertrailActionsCreateSystemIpAddressDestinationIdGroupAndSearchsWithoutSystems(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1",
-		0,
-		0,
-		"15.21.10.1",
-		"ip-address",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		false,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1",
+		DestinationPort:    0,
+		DestinationId:      0,
+		IpAddress:          "15.21.10.1",
+		SystemType:         "ip-address",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   false,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	defer testDeleteOnlySystemIpAddressDestinationPort(t, *options, createdItems)
 	defer testDeleteGroupAndSearchsWithSystemBasedInDestinationPort(t, *options, createdItems)
 	defer testDeleteOnlySearchsWithSystemBasedInDestinationPort(t, *options, createdItems)
@@ -499,7 +515,7 @@ func testDeleteSystemIpAddressDestinationPortGroupSearchsAndSystems(t *testing.T
 	options.DeleteAllSearches = true
 	options.DeleteAllSystems = true
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -517,27 +533,28 @@ func testDeleteSystemIpAddressDestinationPortGroupSearchsAndSystems(t *testing.T
 }
 
 func TestApp_PapertrailActionsCreateSystemIpAddressDestinationIdGroupAndSearchs(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1",
-		0,
-		0,
-		"15.21.10.1",
-		"ip-address",
-		"default search test",
-		"*",
-		"c",
-		true,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1",
+		DestinationPort:    0,
+		DestinationId:      0,
+		IpAddress:          "15.21.10.1",
+		SystemType:         "ip-address",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  true,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	defer testDeleteSystemIpAddressDestinationPortGroupSearchsAndSystems(t, *options, createdItems)
 	expectedCreatedSystem1 := NewItem(0, "System", "15.21.10.1", true, false)
 	expectedCreatedGroup := NewItem(0, "Group", "group-test", true, false)
@@ -553,57 +570,60 @@ func TestApp_PapertrailActionsCreateSystemIpAddressDestinationIdGroupAndSearchs(
 }
 
 func TestApp_PapertrailActionsCreateSystemIpAddressInvalid(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"10.1.2.11",
-		0,
-		0,
-		"192.168.0.1",
-		"ip-address",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	_, _, err := app.PapertrailActions(options)
-	expectedError := convertStatusCodeToError(400, "System", "Creating")
-	if err.Error() != expectedError.Error() {
+		GroupName:          "group-test",
+		SystemWildcard:     "10.1.2.11",
+		DestinationPort:    0,
+		DestinationId:      0,
+		IpAddress:          "192.168.0.1",
+		SystemType:         "ip-address",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	_, _, err := app.PapertrailActions(context.Background(), options)
+	var validationError *ValidationError
+	if !errors.As(err, &validationError) || validationError.StatusCode != 400 ||
+		validationError.Resource != "System" || validationError.Action != "Creating" {
 		t.Fatal("The error obtained is not the expected")
 	}
 }
 
 func TestApp_PapertrailActionsInvalidadDestinationId(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		0,
-		177547777692,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	_, _, err := app.PapertrailActions(options)
-	expectedError := errors.New("Error: Destination not found ")
-	if err.Error() != expectedError.Error() {
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    0,
+		DestinationId:      177547777692,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	_, _, err := app.PapertrailActions(context.Background(), options)
+	var notFoundError *NotFoundError
+	if !errors.Is(err, ErrNotFound) || !errors.As(err, &notFoundError) || notFoundError.Resource != "Destination" {
 		t.Fatal("The error obtained is not the expected")
 	}
 }
@@ -613,7 +633,7 @@ func testDeleteSystemsHostnameDestinationPortGroupAndSearchsDeleteAll(t *testing
 	options.Action = "delete"
 	options.DeleteAllSearches = true
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -631,27 +651,28 @@ func testDeleteSystemsHostnameDestinationPortGroupAndSearchsDeleteAll(t *testing
 }
 
 func TestApp_PapertrailActionsCreateRepeatedSystemsHostnameDestinationPortGroupAndSearchs(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"10.1.2.11, 10.1.2.11",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "10.1.2.11, 10.1.2.11",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	defer testDeleteSystemsHostnameDestinationPortGroupAndSearchsDeleteAll(t, *options, createdItems)
 	expectedCreatedSystem := NewItem(0, "System", "10.1.2.11", true, false)
 	expectedCreatedGroup := NewItem(0, "Group", "group-test", true, false)
@@ -670,7 +691,7 @@ func testDeleteOnlySearchsWithSystemBasedInDestinationPort(t *testing.T, options
 	options.DeleteAllSystems = false
 	options.Action = "delete"
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -689,7 +710,7 @@ func testDeleteGroupAndSearchsWithSystemBasedInDestinationPort(t *testing.T, opt
 	options.DeleteAllSearches = true
 	options.Action = "delete"
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -708,7 +729,7 @@ func testDeleteGroupAndSearchsWithoutSystems(t *testing.T, options Options, crea
 	options.DeleteAllSearches = true
 	options.Action = "delete"
 	app := &App{}
-	deletedItems, _, err := app.PapertrailActions(&options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), &options)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -723,27 +744,28 @@ func testDeleteGroupAndSearchsWithoutSystems(t *testing.T, options Options, crea
 }
 
 func TestApp_PapertrailActionsDeleteInvalidGroup(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		false,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   false,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	expectedCreatedSystem1 := NewItem(0, "System", "15.21.10.1", true, false)
 	expectedCreatedSystem2 := NewItem(0, "System", "3.2.13.90", true, false)
 	expectedCreatedGroup := NewItem(0, "Group", "group-test", true, false)
@@ -762,7 +784,7 @@ func TestApp_PapertrailActionsDeleteInvalidGroup(t *testing.T) {
 	options.DeleteAllSystems = false
 	options.Action = "delete"
 	options.GroupName = "group-test invalid"
-	deletedItems, _, err := app.PapertrailActions(options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), options)
 	errExpected := errors.New("Error: Group with name " + options.GroupName + " doesn't exist ")
 	if err.Error() != errExpected.Error() {
 		t.Fatal("The error obtained is not the expected")
@@ -773,27 +795,28 @@ func TestApp_PapertrailActionsDeleteInvalidGroup(t *testing.T) {
 }
 
 func TestApp_PapertrailActionsDeleteOnlySystems(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	expectedCreatedSystem1 := NewItem(0, "System", "15.21.10.1", true, false)
 	expectedCreatedSystem2 := NewItem(0, "System", "3.2.13.90", true, false)
 	expectedCreatedGroup := NewItem(0, "Group", "group-test", true, false)
@@ -810,7 +833,7 @@ func TestApp_PapertrailActionsDeleteOnlySystems(t *testing.T) {
 	defer testDeleteGroupAndSearchsWithoutSystems(t, *options, createdItems)
 	options.Action = "delete"
 	options.DeleteOnlySystems = true
-	deletedItems, _, _ := app.PapertrailActions(options)
+	deletedItems, _, _ := app.PapertrailActions(context.Background(), options)
 	expectedDeletedSystem1 := NewItem(createdItems[0].ID, createdItems[0].ItemType,
 		createdItems[0].ItemName, false, true)
 	expectedDeletedSystem2 := NewItem(createdItems[1].ID, createdItems[1].ItemType,
@@ -825,27 +848,28 @@ func TestApp_PapertrailActionsDeleteOnlySystems(t *testing.T) {
 }
 
 func TestApp_PapertrailActionsDeleteInvalidSearch(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	expectedCreatedSystem1 := NewItem(0, "System", "15.21.10.1", true, false)
 	expectedCreatedSystem2 := NewItem(0, "System", "3.2.13.90", true, false)
 	expectedCreatedGroup := NewItem(0, "Group", "group-test", true, false)
@@ -862,7 +886,7 @@ func TestApp_PapertrailActionsDeleteInvalidSearch(t *testing.T) {
 	defer testDeleteGroupAndSearchsWithoutSystems(t, *options, createdItems)
 	options.Action = "delete"
 	options.Search = "default search invalid"
-	deletedItems, _, err := app.PapertrailActions(options)
+	deletedItems, _, err := app.PapertrailActions(context.Background(), options)
 	errExpected := errors.New("Error: Search with name " + options.Search + " doesn't exist")
 	if err.Error() != errExpected.Error() {
 		t.Fatal("The error obtained is not the expected")
@@ -885,7 +909,7 @@ func EqualItems(a, b []Item) bool {
 		return false
 	}
 	for i, v := range a {
-		if v != b[i] {
+		if !reflect.DeepEqual(v, b[i]) {
 			return false
 		}
 	}
@@ -893,27 +917,28 @@ func EqualItems(a, b []Item) bool {
 }
 
 func TestApp_PapertrailActionsObtainLogsSystemsHostnameDestinationPortGroupAndSearchs(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"c",
-		false,
-		false,
-		true,
-		false,
-		nowDateLessEightHours,
-		nowDate,
-		"/tmp/",
-	}
-	createdItems, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "c",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDateLessEightHours,
+		EndDate:            nowDate,
+		Path:               "/tmp/",
+	}
+	createdItems, _, err := app.PapertrailActions(context.Background(), options)
 	defer testDeleteSystemsHostnameDestinationPortGroupAndAllSearchs(t, *options, createdItems)
 	expectedCreatedSystem1 := NewItem(0, "System", "15.21.10.1", true, false)
 	expectedCreatedSystem2 := NewItem(0, "System", "3.2.13.90", true, false)
@@ -929,16 +954,18 @@ func TestApp_PapertrailActionsObtainLogsSystemsHostnameDestinationPortGroupAndSe
 		log.Fatal(err)
 	}
 	options.Action = "obtain"
-	obtainedItems, _, err := app.PapertrailActions(options)
+	obtainedItems, _, err := app.PapertrailActions(context.Background(), options)
 	unixStartDate, _ := GetTimeStampUnixFromDate(options.StartDate)
 	unixEndDate, _ := GetTimeStampUnixFromDate(options.EndDate)
-	itemExpectedName := CreateFilenameForEventsSearch(options.Path, options.GroupName, options.Search, unixStartDate, unixEndDate) + " with 0 events retrieved"
+	itemExpectedPath := CreateFilenameForEventsSearch(options.Path, options.GroupName, options.Search, unixStartDate, unixEndDate)
 	obtainedItemExpected := Item{
-		ID:       0,
-		ItemType: "EventsSearch",
-		ItemName: itemExpectedName,
-		Created:  false,
-		Deleted:  false,
+		ID:         0,
+		ItemType:   "EventsSearch",
+		ItemName:   itemExpectedPath + " with 0 events retrieved",
+		Created:    false,
+		Deleted:    false,
+		FilePath:   itemExpectedPath,
+		EventCount: 0,
 	}
 	obtainedItemsExpected := []Item{obtainedItemExpected}
 	if err != nil {
@@ -953,24 +980,24 @@ func TestApp_PapertrailActionsObtainIncorrectStartDate(t *testing.T) {
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"o",
-		false,
-		false,
-		true,
-		false,
-		"14/08/2020 10:20:00",
-		"04/08/2020 10:40:00",
-		"/tmp/",
-	}
-	_, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "o",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          "14/08/2020 10:20:00",
+		EndDate:            "04/08/2020 10:40:00",
+		Path:               "/tmp/",
+	}
+	_, _, err := app.PapertrailActions(context.Background(), options)
 	expectedError := fmt.Errorf("cannot parse startdate: parsing time \"%v\": month out of range", options.StartDate)
 	if err.Error() != expectedError.Error() {
 		t.Fatal("The error obtained is not the expected")
@@ -981,24 +1008,24 @@ func TestApp_PapertrailActionsObtainIncorrectEndDate(t *testing.T) {
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"o",
-		false,
-		false,
-		true,
-		false,
-		"04/08/2020 10:20:00",
-		"14/08/2020 10:40:00",
-		"/tmp/",
-	}
-	_, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "o",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          "04/08/2020 10:20:00",
+		EndDate:            "14/08/2020 10:40:00",
+		Path:               "/tmp/",
+	}
+	_, _, err := app.PapertrailActions(context.Background(), options)
 	expectedError := fmt.Errorf("cannot parse enddate: parsing time \"%v\": month out of range", options.EndDate)
 	if err.Error() != expectedError.Error() {
 		t.Fatal("The error obtained is not the expected")
@@ -1006,27 +1033,28 @@ func TestApp_PapertrailActionsObtainIncorrectEndDate(t *testing.T) {
 }
 
 func TestApp_PapertrailActionsObtainIncorrectDates(t *testing.T) {
+	requireIntegrationEnv(t)
 	defer os.Setenv("PAPERTRAIL_API_TOKEN", papertrailApiToken)
 	app := &App{}
 	options := &Options{
-		"group-test",
-		"15.21.10.1, 3.2.13.90",
-		destinationDefaultPort,
-		0,
-		"",
-		"hostname",
-		"default search test",
-		"*",
-		"o",
-		false,
-		false,
-		true,
-		false,
-		nowDate,
-		nowDateLessEightHours,
-		"/tmp/",
-	}
-	_, _, err := app.PapertrailActions(options)
+		GroupName:          "group-test",
+		SystemWildcard:     "15.21.10.1, 3.2.13.90",
+		DestinationPort:    destinationDefaultPort,
+		DestinationId:      0,
+		IpAddress:          "",
+		SystemType:         "hostname",
+		Search:             "default search test",
+		Query:              "*",
+		Action:             "o",
+		DeleteAllSearches:  false,
+		DeleteOnlySearches: false,
+		DeleteAllSystems:   true,
+		DeleteOnlySystems:  false,
+		StartDate:          nowDate,
+		EndDate:            nowDateLessEightHours,
+		Path:               "/tmp/",
+	}
+	_, _, err := app.PapertrailActions(context.Background(), options)
 	expectedError := errors.New("startdate > enddate - please set proper data boundaries")
 	if err.Error() != expectedError.Error() {
 		t.Fatal("The error obtained is not the expected")
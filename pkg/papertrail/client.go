@@ -0,0 +1,187 @@
+package papertrail
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultUserAgent is the user agent sent when none has been configured in the client
+const defaultUserAgent = "go-papertrail-cli"
+
+// Default values used for the retries of the requests that fail with a transient error
+const (
+	defaultMaxRetries     = 5
+	defaultRetryBaseDelay = time.Second
+)
+
+// Client contains the necessary information to interact with papertrail's API.
+// Several clients can be used side by side, each one with its own configuration
+type Client struct {
+
+	// Base URL for all API operations in papertrail
+	BaseURL string
+
+	// Token used to authenticate the requests against papertrail's API
+	Token string
+
+	// HTTP client used to send the requests
+	HTTPClient *http.Client
+
+	// User agent sent in every request
+	UserAgent string
+
+	// Logger used to report the actions carried out
+	Logger *log.Logger
+
+	// Rate limiter used to not exceed the request budget of the account, it can
+	// be shared by several clients using the same account
+	RateLimiter *RateLimiter
+
+	// Maximum number of retries of a request answered with a rate limit
+	// or a transient server error
+	MaxRetries int
+
+	// Base delay of the exponential backoff used between retries
+	RetryBaseDelay time.Duration
+
+	// Reject the responses with fields unknown to the client, making
+	// the changes in the schema of papertrail's API visible early
+	StrictSchema bool
+}
+
+// NewClient allows to create a Client type struct providing all the information for it,
+// the values not provided are replaced by their defaults
+func NewClient(baseURL string, token string, httpClient *http.Client, userAgent string, logger *log.Logger) *Client {
+	if len(baseURL) == 0 {
+		baseURL = papertrailApiBaseUrl
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	if len(userAgent) == 0 {
+		userAgent = defaultUserAgent
+	}
+	if logger == nil {
+		logger = log.New(log.Writer(), log.Prefix(), log.Flags())
+	}
+	return &Client{BaseURL: baseURL, Token: token, HTTPClient: httpClient, UserAgent: userAgent, Logger: logger,
+		RateLimiter: NewRateLimiter(), MaxRetries: defaultMaxRetries, RetryBaseDelay: defaultRetryBaseDelay}
+}
+
+// NewClientFromEnv creates a client with the default configuration, obtaining the
+// token necessary for interact with papertrail from the environment variable PAPERTRAIL_API_TOKEN
+func NewClientFromEnv() *Client {
+	return NewClient("", os.Getenv("PAPERTRAIL_API_TOKEN"), nil, "", nil)
+}
+
+// url returns the full URL of the endpoint provided using the base URL of the client
+func (c *Client) url(endpoint string) string {
+	baseURL := c.BaseURL
+	if len(baseURL) == 0 {
+		baseURL = papertrailApiBaseUrl
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/" + strings.TrimPrefix(endpoint, "/")
+}
+
+// endpointWithId returns the endpoint of a specific element of the collection
+// endpoint provided, e.g. groups.json becomes groups/<id>.json
+func endpointWithId(endpoint string, id int64) string {
+	return strings.TrimSuffix(endpoint, ".json") + "/" + strconv.FormatInt(id, 10) + ".json"
+}
+
+// logf prints a message through the logger of the client
+func (c *Client) logf(format string, v ...interface{}) {
+	if c.Logger == nil {
+		log.Printf(format, v...)
+		return
+	}
+	c.Logger.Printf(format, v...)
+}
+
+// apiOperation is a generic function to interact with the papertrail API, in which
+// a series of headers necessary for the interaction with this API are established.
+// Through the parameters it is possible to indicate the type of operation, the body to be sent
+// and the specific endpoint of the API. The request is cancelled when the context provided is done.
+// The requests wait for the rate limit budget and are retried when they are rate limited
+// or fail with a transient server error
+func (c *Client) apiOperation(ctx context.Context, method string, endpoint string, bodyToSend io.Reader) (*ApiResponse, error) {
+	var body []byte
+	if bodyToSend != nil {
+		var err error
+		body, err = ioutil.ReadAll(bodyToSend)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		apiResponse, header, err := c.doRequest(ctx, method, endpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		if c.RateLimiter != nil {
+			c.RateLimiter.Update(header)
+		}
+		if attempt >= c.MaxRetries || !isRetryableStatusCode(method, apiResponse.StatusCode) {
+			return apiResponse, nil
+		}
+		delay := retryDelay(attempt, c.RetryBaseDelay, apiResponse.StatusCode, header, c.RateLimiter)
+		c.logf("Status Code %d received from %s %s, retrying in %s (%d/%d)\n", apiResponse.StatusCode,
+			method, endpoint, delay.Round(time.Millisecond), attempt+1, c.MaxRetries)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// doRequest sends a single request to the papertrail API, returning the response
+// obtained as well as its headers
+func (c *Client) doRequest(ctx context.Context, method string, endpoint string, body []byte) (*ApiResponse, http.Header, error) {
+	var bodyToSend io.Reader
+	if body != nil {
+		bodyToSend = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.url(endpoint), bodyToSend)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Add(papertrailTokenName, c.Token)
+	req.Header.Add("Content-Type", "application/json")
+	userAgent := c.UserAgent
+	if len(userAgent) == 0 {
+		userAgent = defaultUserAgent
+	}
+	req.Header.Set("User-Agent", userAgent)
+	// Send req using the http Client configured
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ApiResponse{
+		Method:     method,
+		URL:        req.URL.String(),
+		Body:       respBody,
+		StatusCode: resp.StatusCode,
+	}, resp.Header, nil
+}
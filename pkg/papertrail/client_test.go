@@ -0,0 +1,397 @@
+package papertrail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakePapertrail is an in-memory implementation of the subset of papertrail's
+// API used by the client, used to run tests without a papertrail account
+type fakePapertrail struct {
+	mu           sync.Mutex
+	token        string
+	nextId       int
+	systems      []System
+	destinations []Destination
+	groups       []GroupObject
+	searches     []SearchObject
+	requests     []*http.Request
+}
+
+// fakeSystemToCreate is the structure used by the fake papertrail to decode the systems to be created
+type fakeSystemToCreate struct {
+	System struct {
+		Name      string `json:"name"`
+		Hostname  string `json:"hostname"`
+		IPAddress string `json:"ip_address"`
+	} `json:"system"`
+	DestinationPort int `json:"destination_port"`
+	DestinationID   int `json:"destination_id"`
+}
+
+// fakeElementPath matches the paths of the fake papertrail used to get, update or delete an element by its identifier
+var fakeElementPath = regexp.MustCompile(`^/api/v1/(systems|groups|searches)/([0-9]+)\.json$`)
+
+// newFakePapertrail starts a fake papertrail server that only accepts the token provided
+func newFakePapertrail(t *testing.T, token string) (*fakePapertrail, *httptest.Server) {
+	fake := &fakePapertrail{token: token, nextId: 1}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func (f *fakePapertrail) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r)
+	if r.Header.Get(papertrailTokenName) != f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	body, _ := ioutil.ReadAll(r.Body)
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api/v1/groups.json":
+		writeJson(w, f.groups)
+	case r.Method == "POST" && r.URL.Path == "/api/v1/groups.json":
+		var groupToCreate GroupCreationObject
+		json.Unmarshal(body, &groupToCreate)
+		group := GroupObject{ID: f.nextId, Name: groupToCreate.Group.Name, SystemWildcard: groupToCreate.Group.SystemWildcard}
+		f.nextId++
+		f.groups = append(f.groups, group)
+		writeJson(w, group)
+	case r.Method == "GET" && r.URL.Path == "/api/v1/searches.json":
+		writeJson(w, f.searches)
+	case r.Method == "POST" && r.URL.Path == "/api/v1/searches.json":
+		var searchToCreate SearchToCreateObject
+		json.Unmarshal(body, &searchToCreate)
+		search := SearchObject{ID: f.nextId, Name: searchToCreate.Name, Query: searchToCreate.Query,
+			Group: SearchGroup{ID: searchToCreate.GroupID, Name: f.groupName(searchToCreate.GroupID)}}
+		f.nextId++
+		f.searches = append(f.searches, search)
+		writeJson(w, search)
+	case r.Method == "GET" && r.URL.Path == "/api/v1/systems.json":
+		writeJson(w, f.systems)
+	case r.Method == "POST" && r.URL.Path == "/api/v1/systems.json":
+		var systemToCreate fakeSystemToCreate
+		json.Unmarshal(body, &systemToCreate)
+		system := System{ID: int64(f.nextId), Name: systemToCreate.System.Name, Hostname: systemToCreate.System.Hostname,
+			Syslog: Syslog{Port: systemToCreate.DestinationPort}}
+		if len(systemToCreate.System.IPAddress) > 0 {
+			system.IPAddress = systemToCreate.System.IPAddress
+		}
+		for _, destination := range f.destinations {
+			if destination.ID == systemToCreate.DestinationID {
+				system.Syslog.Port = destination.Syslog.Port
+			}
+		}
+		f.nextId++
+		f.systems = append(f.systems, system)
+		writeJson(w, system)
+	case r.Method == "GET" && r.URL.Path == "/api/v1/destinations.json":
+		writeJson(w, f.destinations)
+	case fakeElementPath.MatchString(r.URL.Path):
+		match := fakeElementPath.FindStringSubmatch(r.URL.Path)
+		id, _ := strconv.Atoi(match[2])
+		f.serveElement(w, r.Method, match[1], id, body)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// serveElement gets, updates or deletes the element of the fake papertrail with the type and identifier provided
+func (f *fakePapertrail) serveElement(w http.ResponseWriter, method string, resource string, id int, body []byte) {
+	switch resource {
+	case "systems":
+		for index, system := range f.systems {
+			if system.ID == int64(id) {
+				switch method {
+				case "PUT":
+					var systemToUpdate SystemToUpdate
+					json.Unmarshal(body, &systemToUpdate)
+					f.updateSystem(&system, systemToUpdate)
+					f.systems[index] = system
+				case "DELETE":
+					f.systems = append(f.systems[:index], f.systems[index+1:]...)
+				}
+				writeJson(w, system)
+				return
+			}
+		}
+	case "groups":
+		for index, group := range f.groups {
+			if group.ID == id {
+				switch method {
+				case "PUT":
+					var groupToUpdate GroupCreationObject
+					json.Unmarshal(body, &groupToUpdate)
+					group.Name, group.SystemWildcard = groupToUpdate.Group.Name, groupToUpdate.Group.SystemWildcard
+					f.groups[index] = group
+				case "DELETE":
+					f.groups = append(f.groups[:index], f.groups[index+1:]...)
+				}
+				writeJson(w, group)
+				return
+			}
+		}
+	case "searches":
+		for index, search := range f.searches {
+			if search.ID == id {
+				switch method {
+				case "PUT":
+					var searchToUpdate SearchToCreateObject
+					json.Unmarshal(body, &searchToUpdate)
+					search.Name, search.Query = searchToUpdate.Name, searchToUpdate.Query
+					search.Group = SearchGroup{ID: searchToUpdate.GroupID, Name: f.groupName(searchToUpdate.GroupID)}
+					f.searches[index] = search
+				case "DELETE":
+					f.searches = append(f.searches[:index], f.searches[index+1:]...)
+				}
+				writeJson(w, search)
+				return
+			}
+		}
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// updateSystem applies to a system of the fake papertrail the fields provided to update it
+func (f *fakePapertrail) updateSystem(system *System, systemToUpdate SystemToUpdate) {
+	if len(systemToUpdate.System.Name) > 0 {
+		system.Name = systemToUpdate.System.Name
+	}
+	if len(systemToUpdate.System.Hostname) > 0 {
+		system.Hostname = systemToUpdate.System.Hostname
+	}
+	if systemToUpdate.System.AutoDelete != nil {
+		system.AutoDelete = *systemToUpdate.System.AutoDelete
+	}
+	if systemToUpdate.DestinationPort != 0 {
+		system.Syslog.Port = systemToUpdate.DestinationPort
+	}
+	for _, destination := range f.destinations {
+		if destination.ID == systemToUpdate.DestinationID {
+			system.Syslog.Port = destination.Syslog.Port
+		}
+	}
+}
+
+// groupName returns the name of the group of the fake papertrail with the identifier provided
+func (f *fakePapertrail) groupName(groupId int) string {
+	for _, group := range f.groups {
+		if group.ID == groupId {
+			return group.Name
+		}
+	}
+	return ""
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func TestClient_ApiOperationUsesConfiguration(t *testing.T) {
+	fake, server := newFakePapertrail(t, "token-a")
+	var logs bytes.Buffer
+	client := NewClient(server.URL+"/api/v1/", "token-a", server.Client(), "my-tool/1.0", log.New(&logs, "", 0))
+	group, err := client.createPapertrailGroupOperation(context.Background(), "group-test", "*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if group.ID != 1 || group.Name != "group-test" {
+		t.Fatalf("unexpected group created: %+v", group)
+	}
+	if userAgent := fake.requests[0].Header.Get("User-Agent"); userAgent != "my-tool/1.0" {
+		t.Fatalf("unexpected user agent %q", userAgent)
+	}
+	if !strings.Contains(logs.String(), "Group with name group-test and id 1 was successfully created") {
+		t.Fatalf("the message expected was not logged through the client logger: %q", logs.String())
+	}
+}
+
+func TestClient_ApiOperationCancelled(t *testing.T) {
+	_, server := newFakePapertrail(t, "token-a")
+	client := NewClient(server.URL+"/api/v1/", "token-a", nil, "", log.New(ioutil.Discard, "", 0))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := client.checkGroupExists(ctx, "group-test")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected a cancellation error, obtained %v", err)
+	}
+}
+
+func TestApp_PapertrailActionsTwoAccountsSideBySide(t *testing.T) {
+	fakeA, serverA := newFakePapertrail(t, "token-a")
+	fakeB, serverB := newFakePapertrail(t, "token-b")
+	logger := log.New(ioutil.Discard, "", 0)
+	appA := NewApp(NewClient(serverA.URL+"/api/v1", "token-a", nil, "", logger))
+	appB := NewApp(NewClient(serverB.URL+"/api/v1", "token-b", nil, "", logger))
+	options := Options{
+		GroupName:       "group-test",
+		SystemWildcard:  "*",
+		DestinationPort: 7777,
+		SystemType:      "hostname",
+		Search:          "default search test",
+		Query:           "*",
+		Action:          "c",
+		StartDate:       nowDateLessEightHours,
+		EndDate:         nowDate,
+		Path:            "/tmp/",
+	}
+	createdItemsA, _, err := appA.PapertrailActions(context.Background(), &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	options.GroupName = "other-group-test"
+	createdItemsB, _, err := appB.PapertrailActions(context.Background(), &options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(createdItemsA) != 2 || len(createdItemsB) != 2 {
+		t.Fatalf("unexpected items created: %+v %+v", createdItemsA, createdItemsB)
+	}
+	if len(fakeA.groups) != 1 || fakeA.groups[0].Name != "group-test" {
+		t.Fatalf("unexpected groups in first account: %+v", fakeA.groups)
+	}
+	if len(fakeB.groups) != 1 || fakeB.groups[0].Name != "other-group-test" {
+		t.Fatalf("unexpected groups in second account: %+v", fakeB.groups)
+	}
+}
+
+func TestClient_FindSystem(t *testing.T) {
+	server := newStaticServer(t, http.StatusOK, `[
+		{"id": 1, "name": "web-1", "hostname": "web-1.example.com", "ip_address": null},
+		{"id": 2, "name": "db", "hostname": "db-1.example.com", "ip_address": "10.0.0.2"},
+		{"id": 3, "name": "db", "hostname": "db-2.example.com", "ip_address": "10.0.0.3"}]`)
+	client := newTestClient(server.URL, "token")
+	tests := []struct {
+		reference string
+		id        int64
+		errorText string
+	}{
+		{reference: "web-1", id: 1},
+		{reference: "db-2.example.com", id: 3},
+		{reference: "10.0.0.2", id: 2},
+		{reference: "db", errorText: "several systems"},
+		{reference: "unknown", errorText: "System unknown doesn't exist"},
+	}
+	for _, test := range tests {
+		system, err := client.FindSystem(context.Background(), test.reference)
+		if len(test.errorText) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.errorText) {
+				t.Errorf("expected error containing %q for %s, obtained %v", test.errorText, test.reference, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %s: %v", test.reference, err)
+		} else if system.ID != test.id {
+			t.Errorf("expected system %d for %s, obtained %d", test.id, test.reference, system.ID)
+		}
+	}
+}
+
+func TestClient_FindSystems(t *testing.T) {
+	server := newStaticServer(t, http.StatusOK, `[
+		{"id": 1, "name": "web-1", "hostname": "web-1.example.com", "ip_address": null},
+		{"id": 2, "name": "db", "hostname": "db-1.example.com", "ip_address": "10.0.0.2"}]`)
+	client := newTestClient(server.URL, "token")
+	systems, err := client.FindSystems(context.Background(), []string{"db-1.example.com", "1", "web-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(systems) != 2 || systems[0].ID != 2 || systems[1].ID != 1 {
+		t.Errorf("expected the systems 2 and 1, obtained %+v", systems)
+	}
+	if _, err := client.FindSystems(context.Background(), []string{"web-1", "unknown"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a not found error, obtained %v", err)
+	}
+}
+
+func TestApp_PapertrailActionsUpdatesExistingGroupAndSearch(t *testing.T) {
+	for _, noUpdate := range []bool{false, true} {
+		fake, server := newFakePapertrail(t, "token")
+		fake.nextId = 100
+		fake.groups = []GroupObject{{ID: 10, Name: "group-test", SystemWildcard: "web-*"}}
+		fake.searches = []SearchObject{{ID: 20, Name: "errors", Query: "error", Group: SearchGroup{ID: 10, Name: "group-test"}}}
+		var logs bytes.Buffer
+		app := NewApp(NewClient(server.URL+"/api/v1/", "token", nil, "", log.New(&logs, "", 0)))
+		items, _, err := app.PapertrailActions(context.Background(), &Options{
+			GroupName:              "group-test",
+			SystemWildcard:         "*",
+			DestinationPort:        7777,
+			SystemType:             "hostname",
+			Search:                 "errors",
+			Query:                  "error OR fatal",
+			Action:                 "c",
+			StartDate:              "-8h",
+			EndDate:                "now",
+			NoUpdate:               noUpdate,
+			SystemWildcardProvided: true,
+			QueryProvided:          true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if noUpdate {
+			if len(items) != 0 || fake.groups[0].SystemWildcard != "web-*" || fake.searches[0].Query != "error" {
+				t.Fatalf("expected the group and the search not to be updated, obtained %+v", items)
+			}
+			if !strings.Contains(logs.String(), "Warning: search with name errors has the query error instead of error OR fatal") {
+				t.Fatalf("expected a warning about the query of the search: %q", logs.String())
+			}
+			continue
+		}
+		expected := []Item{
+			{ID: 10, ItemType: "Group", ItemName: "group-test", Updated: true,
+				Changes: []FieldChange{{Field: "system_wildcard", Before: "web-*", After: "*"}}},
+			{ID: 20, ItemType: "Search", ItemName: "errors", Updated: true,
+				Changes: []FieldChange{{Field: "query", Before: "error", After: "error OR fatal"}}},
+		}
+		if !reflect.DeepEqual(items, expected) {
+			t.Fatalf("unexpected items:\n%+v\nexpected:\n%+v", items, expected)
+		}
+		if fake.groups[0].SystemWildcard != "*" || fake.searches[0].Query != "error OR fatal" || fake.searches[0].Group.ID != 10 {
+			t.Fatalf("unexpected group and search after the updates: %+v %+v", fake.groups, fake.searches)
+		}
+	}
+}
+
+func TestClient_UpdateSystem(t *testing.T) {
+	fake, server := newFakePapertrail(t, "token")
+	fake.destinations = []Destination{{ID: 5, Syslog: Syslog{Port: 2222}}}
+	fake.systems = []System{{ID: 1, Name: "web-1", Hostname: "web-1.example.com", AutoDelete: true, Syslog: Syslog{Port: 1111}}}
+	client := NewClient(server.URL+"/api/v1/", "token", nil, "", log.New(ioutil.Discard, "", 0))
+	autoDelete := false
+	system, err := client.UpdateSystem(context.Background(), 1, SystemUpdate{Name: "web-2", DestinationID: 5, AutoDelete: &autoDelete})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if system.ID != 1 || system.Name != "web-2" || system.Hostname != "web-1.example.com" || system.AutoDelete || system.Syslog.Port != 2222 {
+		t.Fatalf("unexpected system updated: %+v", system)
+	}
+	request := fake.requests[len(fake.requests)-1]
+	if request.Method != "PUT" || request.URL.Path != "/api/v1/systems/1.json" {
+		t.Fatalf("unexpected request %s %s", request.Method, request.URL.Path)
+	}
+	_, err = client.UpdateSystem(context.Background(), 1, SystemUpdate{DestinationID: 5, DestinationPort: 1111})
+	if err == nil || err.Error() != "Error: destination id and destination port can't be provided together " {
+		t.Fatalf("expected error providing both destinations, obtained %v", err)
+	}
+	_, err = client.UpdateSystem(context.Background(), 2, SystemUpdate{Name: "web-3"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found error updating an unknown system, obtained %v", err)
+	}
+}
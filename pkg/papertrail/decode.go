@@ -0,0 +1,68 @@
+package papertrail
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// DecodeError is returned when the body of a papertrail's response can't be decoded
+// in the structure expected, e.g. because an HTML page was received or the schema changed
+type DecodeError struct {
+
+	// HTTP method of the request
+	Method string
+
+	// URL of the request
+	URL string
+
+	// Beginning of the body received
+	Snippet string
+
+	// Error obtained decoding the body
+	Err error
+}
+
+// Error returns the description of the error, including the request and the payload received
+func (e *DecodeError) Error() string {
+	return "Error: decoding response of " + e.Method + " " + e.URL + ": " + e.Err.Error() +
+		", payload received: " + e.Snippet
+}
+
+// Unwrap returns the error obtained decoding the body
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// decodeResponse decodes the JSON body of a papertrail's response in the value provided, failing
+// if the body is not a single valid JSON document. When strict schema is enabled in the client
+// the fields received which are not part of the structure expected are rejected as well
+func (c *Client) decodeResponse(resp *ApiResponse, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(resp.Body))
+	if c.StrictSchema {
+		decoder.DisallowUnknownFields()
+	}
+	err := decoder.Decode(v)
+	if err == nil {
+		if _, errEOF := decoder.Token(); errEOF != io.EOF {
+			err = errors.New("unexpected data after the JSON document")
+		}
+	}
+	if err != nil {
+		return newDecodeError(resp, err)
+	}
+	return nil
+}
+
+// newDecodeError creates a DecodeError for the response provided
+func newDecodeError(resp *ApiResponse, err error) error {
+	return &DecodeError{Method: resp.Method, URL: resp.URL, Snippet: bodySnippet(resp.Body), Err: err}
+}
+
+// checkCreatedId checks that the element created in papertrail has been assigned an identifier,
+// since otherwise the response received doesn't correspond to the element created
+func checkCreatedId(resp *ApiResponse, id int64, resource string) error {
+	if id == 0 {
+		return newDecodeError(resp, errors.New("the response doesn't contain the id of the "+resource+" created"))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package papertrail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newStaticServer starts a server that answers every request with the status code and body provided
+func newStaticServer(t *testing.T, statusCode int, body string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_DecodeResponseInvalidPayload(t *testing.T) {
+	server := newStaticServer(t, http.StatusOK, "<html><body>Maintenance</body></html>")
+	_, err := newTestClient(server.URL, "token").checkGroupExists(context.Background(), "group-test")
+	var decodeError *DecodeError
+	if !errors.As(err, &decodeError) {
+		t.Fatalf("expected a DecodeError, obtained %v", err)
+	}
+	if !strings.HasSuffix(decodeError.URL, "/api/v1/groups.json") || !strings.Contains(decodeError.Snippet, "Maintenance") {
+		t.Fatalf("the error doesn't identify the endpoint and the payload: %v", err)
+	}
+}
+
+func TestClient_DecodeResponseCreatedWithoutId(t *testing.T) {
+	server := newStaticServer(t, http.StatusOK, "{}")
+	_, err := newTestClient(server.URL, "token").createPapertrailGroupOperation(context.Background(), "group-test", "*")
+	var decodeError *DecodeError
+	if !errors.As(err, &decodeError) {
+		t.Fatalf("expected a DecodeError, obtained %v", err)
+	}
+}
+
+func TestClient_DecodeResponseStrictSchema(t *testing.T) {
+	server := newStaticServer(t, http.StatusOK, `[{"id": 1, "name": "group-test", "new_field": true}]`)
+	client := newTestClient(server.URL, "token")
+	group, err := client.checkGroupExists(context.Background(), "group-test")
+	if err != nil || group == nil || group.ID != 1 {
+		t.Fatalf("unknown fields should be ignored by default, obtained %+v %v", group, err)
+	}
+	client.StrictSchema = true
+	_, err = client.checkGroupExists(context.Background(), "group-test")
+	var decodeError *DecodeError
+	if !errors.As(err, &decodeError) || !strings.Contains(err.Error(), "new_field") {
+		t.Fatalf("expected a DecodeError about the unknown field, obtained %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package papertrail
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxErrorBodySnippet is the maximum number of characters of a response
+// body included in the errors when the body can't be decoded
+const maxErrorBodySnippet = 200
+
+// Errors that allow to check with errors.Is the kind of failure reported by papertrail's API
+var (
+	ErrNotFound     = errors.New("papertrail: not found")
+	ErrRateLimited  = errors.New("papertrail: rate limited")
+	ErrValidation   = errors.New("papertrail: validation failed")
+	ErrUnauthorized = errors.New("papertrail: unauthorized")
+)
+
+// APIError represents an unsuccessful response of papertrail's API
+type APIError struct {
+
+	// HTTP method of the request
+	Method string
+
+	// URL of the request
+	URL string
+
+	// Status code of the response
+	StatusCode int
+
+	// Error message obtained from the response body
+	Message string
+
+	// Resource on which the action was performed, e.g. System
+	Resource string
+
+	// Action performed on the resource, e.g. Creating
+	Action string
+}
+
+// Error returns the description of the error, including the request and the message
+// returned by papertrail if any
+func (e *APIError) Error() string {
+	var msg string
+	if e.StatusCode == http.StatusNotFound {
+		msg = "Error: " + e.Resource + " not found "
+	} else {
+		msg = "Error: " + e.Action + " " + e.Resource + " Status Code " + strconv.Itoa(e.StatusCode) + " received "
+	}
+	if len(e.Method) > 0 {
+		msg += "(" + e.Method + " " + e.URL + ")"
+	}
+	if len(e.Message) > 0 {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// NotFoundError is returned when the element requested doesn't exist in papertrail
+type NotFoundError struct{ APIError }
+
+// Unwrap returns the generic APIError
+func (e *NotFoundError) Unwrap() error { return &e.APIError }
+
+// Is reports whether the target is ErrNotFound
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// RateLimitedError is returned when the request budget of the account has been
+// exceeded and the request could not be completed after retrying it
+type RateLimitedError struct{ APIError }
+
+// Unwrap returns the generic APIError
+func (e *RateLimitedError) Unwrap() error { return &e.APIError }
+
+// Is reports whether the target is ErrRateLimited
+func (e *RateLimitedError) Is(target error) bool { return target == ErrRateLimited }
+
+// ValidationError is returned when papertrail rejects the information sent in the request
+type ValidationError struct{ APIError }
+
+// Unwrap returns the generic APIError
+func (e *ValidationError) Unwrap() error { return &e.APIError }
+
+// Is reports whether the target is ErrValidation
+func (e *ValidationError) Is(target error) bool { return target == ErrValidation }
+
+// UnauthorizedError is returned when the token provided is not valid or
+// doesn't have permissions to perform the request
+type UnauthorizedError struct{ APIError }
+
+// Unwrap returns the generic APIError
+func (e *UnauthorizedError) Unwrap() error { return &e.APIError }
+
+// Is reports whether the target is ErrUnauthorized
+func (e *UnauthorizedError) Is(target error) bool { return target == ErrUnauthorized }
+
+// newNotFoundError creates the error returned when an element looked up by its
+// identifier or name doesn't exist in the lists obtained from papertrail
+func newNotFoundError(resource string, reference string) error {
+	return &NotFoundError{APIError{
+		StatusCode: http.StatusNotFound,
+		Message:    resource + " " + reference + " doesn't exist",
+		Resource:   resource,
+		Action:     "Obtaining",
+	}}
+}
+
+// convertStatusCodeToError converts an unsuccessful response to the error
+// corresponding to its status code
+func convertStatusCodeToError(resp *ApiResponse, resource string, action string) error {
+	apiError := APIError{
+		Method:     resp.Method,
+		URL:        resp.URL,
+		StatusCode: resp.StatusCode,
+		Message:    errorMessageFromBody(resp.Body),
+		Resource:   resource,
+		Action:     action,
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return &NotFoundError{apiError}
+	case http.StatusTooManyRequests:
+		return &RateLimitedError{apiError}
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return &ValidationError{apiError}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &UnauthorizedError{apiError}
+	}
+	return &apiError
+}
+
+// errorMessageFromBody obtains the error message of a papertrail's response body,
+// which is a JSON object with a message field. If the body is not a JSON object
+// a snippet of it is returned
+func errorMessageFromBody(body []byte) string {
+	var errorBody struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &errorBody); err == nil {
+		return errorBody.Message
+	}
+	return bodySnippet(body)
+}
+
+// bodySnippet returns the beginning of a response body to be included in error messages
+func bodySnippet(body []byte) string {
+	snippet := strings.TrimSpace(string(body))
+	if len(snippet) > maxErrorBodySnippet {
+		snippet = snippet[:maxErrorBodySnippet] + "..."
+	}
+	return snippet
+}
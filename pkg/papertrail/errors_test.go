@@ -0,0 +1,45 @@
+package papertrail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertStatusCodeToError(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		body       string
+		kind       error
+		message    string
+	}{
+		{http.StatusNotFound, `{"message":"Not Found"}`, ErrNotFound, "Not Found"},
+		{http.StatusBadRequest, `{"message":"Hostname has already been taken"}`, ErrValidation, "Hostname has already been taken"},
+		{http.StatusUnauthorized, `{"message":"Invalid token"}`, ErrUnauthorized, "Invalid token"},
+		{http.StatusTooManyRequests, `<html>Too Many Requests</html>`, ErrRateLimited, "<html>Too Many Requests</html>"},
+	}
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.statusCode)
+			w.Write([]byte(test.body))
+		}))
+		client := newTestClient(server.URL, "token")
+		client.MaxRetries = 0
+		_, err := client.createPapertrailGroupOperation(context.Background(), "group-test", "*")
+		server.Close()
+		if !errors.Is(err, test.kind) {
+			t.Fatalf("expected error of kind %v for status code %d, obtained %v", test.kind, test.statusCode, err)
+		}
+		var apiError *APIError
+		if !errors.As(err, &apiError) {
+			t.Fatalf("expected an APIError for status code %d, obtained %T", test.statusCode, err)
+		}
+		if apiError.StatusCode != test.statusCode || apiError.Method != "POST" ||
+			!strings.HasSuffix(apiError.URL, "/api/v1/groups.json") || apiError.Message != test.message {
+			t.Fatalf("unexpected error information %+v", apiError)
+		}
+	}
+}
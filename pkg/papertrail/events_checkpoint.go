@@ -0,0 +1,198 @@
+package papertrail
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// eventsCheckpoint is the structure used to persist the progress of an events search after every page
+// obtained, so it can be resumed from the point where it stopped if it is interrupted
+type eventsCheckpoint struct {
+
+	// Group, systems, query and window of the search
+	GroupID   int     `json:"group_id"`
+	SystemIDs []int64 `json:"system_ids,omitempty"`
+	Query     string  `json:"query"`
+	MinTime   int64   `json:"min_time"`
+	MaxTime   int64   `json:"max_time"`
+
+	// Indicates if papertrail reported that not all the events were returned
+	Truncated bool `json:"truncated"`
+
+	// Progress of each one of the sub-windows in which the window is split
+	Shards []*shardCheckpoint `json:"shards"`
+
+	// Size, by name, of the files that already existed when the search started in append mode, so the
+	// events saved in them by an interrupted search can be removed when it is resumed
+	OriginalSizes map[string]int64 `json:"original_sizes,omitempty"`
+}
+
+// shardCheckpoint is the structure used to persist the progress of a sub-window of an events search
+type shardCheckpoint struct {
+
+	// System and window of the shard, the system is 0 if the search is not restricted to systems
+	SystemID int64 `json:"system_id,omitempty"`
+	MinTime  int64 `json:"min_time"`
+	MaxTime  int64 `json:"max_time"`
+
+	// Identifier of the oldest event obtained, from which the previous events are requested
+	MinID string `json:"min_id"`
+
+	// Indicates if all the pages of the shard have been obtained
+	Complete bool `json:"complete"`
+
+	// Number of events and names of the chunk files stored in the spool of the shard
+	EventCount int      `json:"event_count"`
+	Chunks     []string `json:"chunks"`
+}
+
+// newEventsCheckpoint allows to create an eventsCheckpoint type struct for a search that hasn't obtained
+// any page yet, whose window is split in the number of shards provided. When the search is restricted to
+// systems, as papertrail only accepts one system in each request, there are shards for each system
+func newEventsCheckpoint(groupId int, systemIds []int64, query string, minTime int64, maxTime int64, shards int) *eventsCheckpoint {
+	checkpoint := &eventsCheckpoint{GroupID: groupId, SystemIDs: systemIds, Query: query, MinTime: minTime, MaxTime: maxTime}
+	for _, systemId := range shardsSystemIds(systemIds) {
+		for _, window := range splitWindow(minTime, maxTime, shards) {
+			checkpoint.Shards = append(checkpoint.Shards, &shardCheckpoint{SystemID: systemId, MinTime: window[0], MaxTime: window[1]})
+		}
+	}
+	return checkpoint
+}
+
+// shardsSystemIds returns the systems whose events are requested by the shards, only one
+// system with identifier 0 (not sent to papertrail) if the search is not restricted to systems
+func shardsSystemIds(systemIds []int64) []int64 {
+	if len(systemIds) == 0 {
+		return []int64{0}
+	}
+	return systemIds
+}
+
+// splitWindow splits the window between the dates provided, both included, in the number of consecutive
+// sub-windows provided, which is reduced if the window has less seconds
+func splitWindow(minTime int64, maxTime int64, parts int) [][2]int64 {
+	seconds := maxTime - minTime + 1
+	if int64(parts) > seconds {
+		parts = int(seconds)
+	}
+	if parts < 1 {
+		parts = 1
+	}
+	var windows [][2]int64
+	for i := int64(0); i < int64(parts); i++ {
+		windows = append(windows, [2]int64{minTime + i*seconds/int64(parts), minTime + (i+1)*seconds/int64(parts) - 1})
+	}
+	return windows
+}
+
+// complete returns if all the pages of all the shards have been obtained
+func (cp *eventsCheckpoint) complete() bool {
+	for _, shard := range cp.Shards {
+		if !shard.Complete {
+			return false
+		}
+	}
+	return true
+}
+
+// eventCount returns the number of events obtained by all the shards
+func (cp *eventsCheckpoint) eventCount() int {
+	count := 0
+	for _, shard := range cp.Shards {
+		count += shard.EventCount
+	}
+	return count
+}
+
+// checkpointPathFileName returns the path of the checkpoint of the search whose files start with the path provided
+func checkpointPathFileName(pathFileName string) string {
+	return pathFileName + ".checkpoint.json"
+}
+
+// spoolDir returns the path of the directory where the pages of the search whose files
+// start with the path provided are stored until they are written in the files
+func spoolDir(pathFileName string) string {
+	dir, name := filepath.Split(pathFileName)
+	return filepath.Join(dir, "."+name+".spool")
+}
+
+// shardSpoolDir returns the directory, inside the directory of the spool of a search, where the pages of a shard are stored
+func shardSpoolDir(dir string, shard int) string {
+	return filepath.Join(dir, "shard-"+strconv.Itoa(shard))
+}
+
+// readEventsCheckpoint reads the checkpoint stored in the file with the path provided, returning nil if it doesn't exist
+func readEventsCheckpoint(pathFileName string) (*eventsCheckpoint, error) {
+	b, err := ioutil.ReadFile(pathFileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var checkpoint eventsCheckpoint
+	if err := json.Unmarshal(b, &checkpoint); err != nil {
+		return nil, errors.New("Error: the checkpoint " + pathFileName + " is not valid: " + err.Error())
+	}
+	return &checkpoint, nil
+}
+
+// write stores the checkpoint in the file with the path provided, replacing it atomically
+func (cp *eventsCheckpoint) write(pathFileName string) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	file, err := createTempFile(pathFileName)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(b)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), pathFileName)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// matches returns if the checkpoint belongs to the search with the group, systems, query, window and shards provided
+func (cp *eventsCheckpoint) matches(groupId int, systemIds []int64, query string, minTime int64, maxTime int64, shards int) bool {
+	if len(cp.SystemIDs) != len(systemIds) {
+		return false
+	}
+	for index, systemId := range systemIds {
+		if cp.SystemIDs[index] != systemId {
+			return false
+		}
+	}
+	return cp.GroupID == groupId && cp.Query == query && cp.MinTime == minTime && cp.MaxTime == maxTime &&
+		len(cp.Shards) == len(shardsSystemIds(systemIds))*len(splitWindow(minTime, maxTime, shards))
+}
+
+// addPage records that a page of events, sorted in chronological order, has been stored in the spool
+// provided, so the shard is resumed from the oldest event of the page
+func (sc *shardCheckpoint) addPage(events []Events, spool *eventsSpool) {
+	sc.MinID = events[0].ID
+	sc.EventCount = spool.count
+	sc.Chunks = nil
+	for _, chunk := range spool.chunks {
+		sc.Chunks = append(sc.Chunks, filepath.Base(chunk))
+	}
+}
+
+// spool returns the spool, stored in the directory provided, with the pages recorded in the checkpoint of the shard
+func (sc *shardCheckpoint) spool(dir string) *eventsSpool {
+	spool := &eventsSpool{dir: dir, count: sc.EventCount}
+	for _, chunk := range sc.Chunks {
+		spool.chunks = append(spool.chunks, filepath.Join(dir, chunk))
+	}
+	return spool
+}
@@ -0,0 +1,570 @@
+package papertrail
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Compressions available for the files where the log events are saved
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// Modes available to save the log events in files that already exist
+const (
+	FileModeFail      = "fail"
+	FileModeOverwrite = "overwrite"
+	FileModeAppend    = "append"
+)
+
+// EventsExport is the structure used to indicate how the log events obtained in a search are saved
+type EventsExport struct {
+
+	// Path of the directory where the files are saved
+	Path string
+
+	// Formatter used to write the events, only their messages are written if it is not provided
+	Formatter EventsFormatter
+
+	// Compression of the files, possible values none (or empty), gzip or zstd
+	Compression string
+
+	// Maximum size in bytes of the events written in a file before compressing them, not limited if 0
+	MaxFileSize int64
+
+	// Maximum number of events saved in a file, not limited if 0
+	MaxFileEvents int
+
+	// Duration of the time buckets of the received date of the events saved in each file
+	// (e.g. one hour saves in a file the events received in each hour), not used if 0
+	FileInterval time.Duration
+
+	// Indicates if a manifest listing the files is written even if the events are saved in a single file
+	Manifest bool
+
+	// Template of the names of the files, DefaultFilenameTemplate if empty (see CreateFilenameFromTemplate)
+	FilenameTemplate string
+
+	// Layout of the dates in the names of the files, DefaultFilenameTimeFormat if empty
+	FilenameTimeFormat string
+
+	// What to do when a file already exists, possible values fail (or empty), overwrite or append
+	Mode string
+
+	// Indicates if an interrupted search is resumed from its checkpoint, replacing the files it wrote. In append mode
+	// the files keep the content they had before the interrupted search
+	Resume bool
+
+	// Number of sub-windows in which the window of the search is split to obtain their events concurrently, 1 if 0
+	Shards int
+
+	// Maximum number of shards whose events are obtained at the same time, the number of shards (at most 4) if 0
+	Concurrency int
+
+	// Filter selecting the events saved once they have been obtained, all of them are saved if it is not provided
+	Filter *EventsFilter
+}
+
+// NewEventsExport allows to create an EventsExport type struct saving the events in a single
+// uncompressed file in the path provided, written with the formatter provided
+func NewEventsExport(path string, formatter EventsFormatter) *EventsExport {
+	return &EventsExport{Path: path, Formatter: formatter}
+}
+
+// rotated returns if the events are split in several files
+func (e EventsExport) rotated() bool {
+	return e.MaxFileSize > 0 || e.MaxFileEvents > 0 || e.FileInterval > 0
+}
+
+// check checks if valid values are being used to save the events
+func (e EventsExport) check() error {
+	switch strings.ToLower(e.Compression) {
+	case "", CompressionNone, CompressionGzip, CompressionZstd:
+	default:
+		return errors.New("Not valid option provided for compression, the only valid values are: none, gzip or zstd ")
+	}
+	if e.MaxFileSize < 0 || e.MaxFileEvents < 0 || e.FileInterval < 0 {
+		return errors.New("Error: the size, number of events and interval of the files can't be negative ")
+	}
+	if e.Shards < 0 || e.Concurrency < 0 {
+		return errors.New("Error: the number of shards and their concurrency can't be negative ")
+	}
+	switch e.mode() {
+	case FileModeFail, FileModeOverwrite:
+	case FileModeAppend:
+		if e.rotated() || e.Manifest {
+			return errors.New("Error: the events can't be appended to files that are split or described by a manifest ")
+		}
+	default:
+		return errors.New("Not valid option provided for mode, the only valid values are: fail, overwrite or append ")
+	}
+	return nil
+}
+
+// shards returns the number of sub-windows in which the window of the search is split
+func (e EventsExport) shards() int {
+	if e.Shards < 1 {
+		return 1
+	}
+	return e.Shards
+}
+
+// concurrency returns the maximum number of shards, from the total number of shards provided,
+// whose events are obtained at the same time
+func (e EventsExport) concurrency(shards int) int {
+	if e.Concurrency > 0 {
+		return e.Concurrency
+	}
+	if shards < defaultShardsConcurrency {
+		return shards
+	}
+	return defaultShardsConcurrency
+}
+
+// mode returns what to do when a file already exists
+func (e EventsExport) mode() string {
+	if len(e.Mode) == 0 {
+		return FileModeFail
+	}
+	return strings.ToLower(e.Mode)
+}
+
+// checkFilesAvailable checks, when the files that already exist can't be replaced, that the first file
+// where the events are saved and the manifest, whose names start with the path provided, don't exist
+func (e EventsExport) checkFilesAvailable(pathFileName string) error {
+	if e.mode() != FileModeFail {
+		return nil
+	}
+	paths := []string{pathFileName + e.extension()}
+	if e.rotated() {
+		paths = []string{pathFileName + ".0001" + e.extension(), pathFileName + ".manifest.json"}
+	} else if e.Manifest {
+		paths = append(paths, pathFileName+".manifest.json")
+	}
+	for _, path := range paths {
+		if err := checkFileAvailable(path, e.mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// existingFiles returns the paths of the files where the events are saved, whose names start with the path provided,
+// that already exist. The numbered files are looked up in order until one of them doesn't exist
+func (e EventsExport) existingFiles(pathFileName string) ([]string, error) {
+	var paths []string
+	for number := 1; ; number++ {
+		path := pathFileName + e.extension()
+		if e.rotated() {
+			path = fmt.Sprintf("%s.%04d%s", pathFileName, number, e.extension())
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return paths, nil
+		} else if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+		if !e.rotated() {
+			return paths, nil
+		}
+	}
+}
+
+// fileSizes returns the size, by name, of the files where the events are saved, whose names start with the path provided,
+// that already exist
+func (e EventsExport) fileSizes(pathFileName string) (map[string]int64, error) {
+	paths, err := e.existingFiles(pathFileName)
+	if err != nil {
+		return nil, err
+	}
+	sizes := map[string]int64{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		sizes[filepath.Base(path)] = info.Size()
+	}
+	return sizes, nil
+}
+
+// restoreFiles gives back to the files where the events are saved, whose names start with the path provided, the
+// sizes provided, removing the ones without size, so the events appended to them by an interrupted search are discarded
+func (e EventsExport) restoreFiles(pathFileName string, sizes map[string]int64) error {
+	paths, err := e.existingFiles(pathFileName)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if size, found := sizes[filepath.Base(path)]; found {
+			err = os.Truncate(path, size)
+		} else {
+			err = os.Remove(path)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFileAvailable checks that the file with the path provided doesn't exist when the mode is fail
+func checkFileAvailable(pathFileName string, mode string) error {
+	if mode != FileModeFail {
+		return nil
+	}
+	if _, err := os.Stat(pathFileName); err == nil {
+		return errors.New("Error: the file " + pathFileName + " already exists, use overwrite or append mode to replace it or add the events to it ")
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// createTempFile creates a temporary file in the directory of the file with the path provided,
+// which replaces it when it is renamed
+func createTempFile(pathFileName string) (*os.File, error) {
+	file, err := ioutil.TempFile(filepath.Dir(pathFileName), "."+filepath.Base(pathFileName)+".tmp-")
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Chmod(0644); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, err
+	}
+	return file, nil
+}
+
+// extension returns the extension of the files depending on their compression
+func (e EventsExport) extension() string {
+	switch strings.ToLower(e.Compression) {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// EventsFile is the structure used to describe a file where log events have been saved. The ids and
+// dates of the first and last events are empty if no events have been saved in the file
+type EventsFile struct {
+	File         string     `json:"file"`
+	EventCount   int        `json:"event_count"`
+	FirstEventID string     `json:"first_event_id,omitempty"`
+	LastEventID  string     `json:"last_event_id,omitempty"`
+	FirstEventAt *time.Time `json:"first_event_at,omitempty"`
+	LastEventAt  *time.Time `json:"last_event_at,omitempty"`
+	Size         int64      `json:"size"`
+	SHA256       string     `json:"sha256"`
+}
+
+// EventsManifest is the structure used to describe the files where the log events of a search have been saved
+type EventsManifest struct {
+	Group       string       `json:"group"`
+	SystemIDs   []int64      `json:"system_ids,omitempty"`
+	Search      string       `json:"search"`
+	Query       string       `json:"query"`
+	MinTime     time.Time    `json:"min_time"`
+	MaxTime     time.Time    `json:"max_time"`
+	Compression string       `json:"compression"`
+	EventCount  int          `json:"event_count"`
+	Truncated   bool         `json:"truncated"`
+	Files       []EventsFile `json:"files"`
+}
+
+// writeEventsManifest writes the manifest provided as indented JSON in the file with the path provided,
+// which is replaced atomically unless it already exists and the mode is fail
+func writeEventsManifest(pathFileName string, manifest EventsManifest, mode string) error {
+	if len(mode) == 0 {
+		mode = FileModeFail
+	}
+	if err := checkFileAvailable(pathFileName, strings.ToLower(mode)); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	file, err := createTempFile(pathFileName)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(b, '\n'))
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), pathFileName)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// eventsFileWriter writes log events, which must be received in chronological order, in the files
+// indicated by an events export, starting a new file each time the limits of the current one are reached
+type eventsFileWriter struct {
+
+	// Configuration of the files where the events are written
+	export EventsExport
+
+	// Path of the files without the number of the file and the extension
+	basePath string
+
+	// Files already closed
+	files []EventsFile
+
+	// Information of the file being written, nil if there isn't any
+	current *EventsFile
+
+	// Temporary file being written, renamed to the path of the current file when it is
+	// complete, and the compressor and buffer writing in it
+	file       *os.File
+	compressor io.WriteCloser
+	buffer     *bufio.Writer
+
+	// Hash of the content of the file being written and number of bytes of the file
+	hash hash.Hash
+	size int64
+
+	// Number of bytes of the events written in the current file before compressing them
+	written int64
+
+	// Start of the time bucket of the events written in the current file
+	bucket time.Time
+}
+
+// newEventsFileWriter creates a writer of the log events in the files indicated by the export
+// provided, whose names are the base path provided followed by their number and extension
+func newEventsFileWriter(basePath string, export EventsExport) *eventsFileWriter {
+	if export.Formatter == nil {
+		export.Formatter = rawFormatter{}
+	}
+	return &eventsFileWriter{export: export, basePath: basePath}
+}
+
+// Write writes the content of the file being written, through the compressor if there is one
+func (w *eventsFileWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+// write writes an event in the current file, starting a new one if it is necessary
+func (w *eventsFileWriter) write(event Events) error {
+	if w.current == nil || w.mustRotate(event) {
+		if err := w.closeFile(); err != nil {
+			return err
+		}
+		if err := w.openFile(event); err != nil {
+			return err
+		}
+	}
+	counter := &countingWriter{w: w.buffer}
+	err := w.export.Formatter.WriteEvent(counter, event)
+	w.written += counter.n
+	if err != nil {
+		return err
+	}
+	receivedAt := event.ReceivedAt
+	if w.current.EventCount == 0 {
+		w.current.FirstEventID = event.ID
+		w.current.FirstEventAt = &receivedAt
+	}
+	w.current.EventCount++
+	w.current.LastEventID = event.ID
+	w.current.LastEventAt = &receivedAt
+	return nil
+}
+
+// mustRotate returns if the event provided must be written in a new file
+func (w *eventsFileWriter) mustRotate(event Events) bool {
+	if w.current.EventCount == 0 {
+		return false
+	}
+	return w.export.MaxFileEvents > 0 && w.current.EventCount >= w.export.MaxFileEvents ||
+		w.export.MaxFileSize > 0 && w.written >= w.export.MaxFileSize ||
+		w.export.FileInterval > 0 && !event.ReceivedAt.UTC().Truncate(w.export.FileInterval).Equal(w.bucket)
+}
+
+// openFile starts a new file, whose time bucket is the one of the event provided. In append mode the
+// content of the file, if it already exists, is copied to the temporary file before the new events
+func (w *eventsFileWriter) openFile(event Events) error {
+	pathFileName := w.basePath
+	if w.export.rotated() {
+		pathFileName += fmt.Sprintf(".%04d", len(w.files)+1)
+	}
+	pathFileName += w.export.extension()
+	if err := checkFileAvailable(pathFileName, w.export.mode()); err != nil {
+		return err
+	}
+	file, err := createTempFile(pathFileName)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.hash = sha256.New()
+	w.size = 0
+	w.written = 0
+	w.current = &EventsFile{File: pathFileName}
+	if w.export.FileInterval > 0 {
+		w.bucket = event.ReceivedAt.UTC().Truncate(w.export.FileInterval)
+	}
+	appended, err := w.copyExistingFile(pathFileName)
+	if err != nil {
+		return err
+	}
+	w.compressor = nil
+	switch strings.ToLower(w.export.Compression) {
+	case CompressionGzip:
+		w.compressor = gzip.NewWriter(w)
+	case CompressionZstd:
+		if w.compressor, err = zstd.NewWriter(w); err != nil {
+			return err
+		}
+	}
+	if w.compressor != nil {
+		w.buffer = bufio.NewWriter(w.compressor)
+	} else {
+		w.buffer = bufio.NewWriter(w)
+	}
+	if appended {
+		return nil
+	}
+	return w.export.Formatter.WriteHeader(w.buffer)
+}
+
+// copyExistingFile copies, in append mode, the content of the file with the path provided to the file being
+// written, returning if it wasn't empty. The compressed files can be appended because both gzip and zstd
+// support concatenated streams
+func (w *eventsFileWriter) copyExistingFile(pathFileName string) (bool, error) {
+	if w.export.mode() != FileModeAppend {
+		return false, nil
+	}
+	existingFile, err := os.Open(pathFileName)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer existingFile.Close()
+	n, err := io.Copy(w, existingFile)
+	return n > 0, err
+}
+
+// closeFile finishes the file being written, if there is one, renaming the temporary
+// file to its path and adding it to the files written
+func (w *eventsFileWriter) closeFile() error {
+	if w.current == nil {
+		return nil
+	}
+	var err error
+	if w.buffer != nil {
+		err = w.buffer.Flush()
+	}
+	if w.compressor != nil {
+		if errCompressor := w.compressor.Close(); err == nil {
+			err = errCompressor
+		}
+	}
+	if errClose := w.file.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(w.file.Name(), w.current.File)
+	}
+	if err != nil {
+		os.Remove(w.file.Name())
+		w.current = nil
+		return err
+	}
+	w.current.Size = w.size
+	w.current.SHA256 = hex.EncodeToString(w.hash.Sum(nil))
+	w.files = append(w.files, *w.current)
+	w.current = nil
+	return nil
+}
+
+// close finishes the file being written and returns all the files written. When the events are not
+// split in several files, an empty file is created if no events have been written
+func (w *eventsFileWriter) close() ([]EventsFile, error) {
+	if len(w.files) == 0 && w.current == nil && !w.export.rotated() {
+		if err := w.openFile(Events{}); err != nil {
+			w.abort()
+			return nil, err
+		}
+	}
+	if err := w.closeFile(); err != nil {
+		return w.files, err
+	}
+	return w.files, w.removeStaleFiles()
+}
+
+// removeStaleFiles removes, in overwrite mode, the numbered files following the last one written, which
+// were written by a previous search with the same path that split its events in more files
+func (w *eventsFileWriter) removeStaleFiles() error {
+	if !w.export.rotated() || w.export.mode() != FileModeOverwrite {
+		return nil
+	}
+	for number := len(w.files) + 1; ; number++ {
+		err := os.Remove(fmt.Sprintf("%s.%04d%s", w.basePath, number, w.export.extension()))
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// abort removes the temporary file being written, if there is one, without replacing its file
+func (w *eventsFileWriter) abort() {
+	if w.current == nil {
+		return
+	}
+	w.file.Close()
+	os.Remove(w.file.Name())
+	w.current = nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// relativeEventsFiles returns the files provided with their names relative to the directory provided
+func relativeEventsFiles(dir string, files []EventsFile) []EventsFile {
+	relativeFiles := make([]EventsFile, 0, len(files))
+	for _, file := range files {
+		if name, err := filepath.Rel(dir, file.File); err == nil {
+			file.File = name
+		}
+		relativeFiles = append(relativeFiles, file)
+	}
+	return relativeFiles
+}
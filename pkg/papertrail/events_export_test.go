@@ -0,0 +1,194 @@
+package papertrail
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEventsFile returns the content of a file where events have been saved, decompressing it if necessary
+func readEventsFile(t *testing.T, pathFileName string, compression string) string {
+	file, err := os.Open(pathFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var reader io.Reader = file
+	switch compression {
+	case CompressionGzip:
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = gzipReader
+	case CompressionZstd:
+		zstdReader, err := zstd.NewReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zstdReader.Close()
+		reader = zstdReader
+	}
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestClient_SearchEvents_Export(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	// The events are received one per minute and their messages take 8 bytes until the tenth one and 9 bytes after it
+	events := eventsEveryMinute(start, 25)
+	tests := []struct {
+		name     string
+		export   EventsExport
+		expected [][2]int
+	}{
+		{
+			name:     "gzip in a single file with manifest",
+			export:   EventsExport{Compression: CompressionGzip, Manifest: true},
+			expected: [][2]int{{1, 25}},
+		},
+		{
+			name:     "zstd rotated by number of events",
+			export:   EventsExport{Compression: CompressionZstd, MaxFileEvents: 10},
+			expected: [][2]int{{1, 10}, {11, 20}, {21, 25}},
+		},
+		{
+			name:     "rotated by size",
+			export:   EventsExport{MaxFileSize: 80},
+			expected: [][2]int{{1, 10}, {11, 19}, {20, 25}},
+		},
+		{
+			name:     "rotated by interval",
+			export:   EventsExport{Compression: CompressionGzip, FileInterval: 10 * time.Minute},
+			expected: [][2]int{{1, 9}, {10, 19}, {20, 25}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var requests []EventsSearchRequest
+			server := newFakeEventsServer(t, events, 100, &requests)
+			dir, err := ioutil.TempDir("", "events-export")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			export := test.export
+			export.Path = dir
+			item, err := newTestClient(server.URL, "token").SearchEvents(context.Background(), "group", 7, "search", "*",
+				start.Unix(), start.Add(time.Hour).Unix(), export)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(item.FilePath, ".manifest.json") || item.EventCount != 25 {
+				t.Fatalf("expected a manifest with 25 events, obtained %s with %d events", item.FilePath, item.EventCount)
+			}
+			b, err := ioutil.ReadFile(item.FilePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var manifest EventsManifest
+			if err := json.Unmarshal(b, &manifest); err != nil {
+				t.Fatal(err)
+			}
+			if manifest.EventCount != 25 || manifest.Group != "group" || !manifest.MinTime.Equal(start) {
+				t.Errorf("unexpected manifest: %+v", manifest)
+			}
+			if len(manifest.Files) != len(test.expected) {
+				t.Fatalf("expected %d files, obtained %+v", len(test.expected), manifest.Files)
+			}
+			for i, file := range manifest.Files {
+				first, last := test.expected[i][0], test.expected[i][1]
+				pathFileName := filepath.Join(dir, file.File)
+				raw, err := ioutil.ReadFile(pathFileName)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sum := sha256.Sum256(raw)
+				if file.SHA256 != hex.EncodeToString(sum[:]) || file.Size != int64(len(raw)) {
+					t.Errorf("unexpected hash or size of the file %s", file.File)
+				}
+				if file.EventCount != last-first+1 || file.FirstEventAt == nil || !file.FirstEventAt.Equal(events[first-1].ReceivedAt) ||
+					file.LastEventAt == nil || !file.LastEventAt.Equal(events[last-1].ReceivedAt) {
+					t.Errorf("unexpected description of the file %d: %+v", i, file)
+				}
+				expected := ""
+				for n := first; n <= last; n++ {
+					expected += events[n-1].Message + "\n"
+				}
+				if content := readEventsFile(t, pathFileName, export.Compression); content != expected {
+					t.Errorf("expected content of the file %s:\n%q\nobtained:\n%q", file.File, expected, content)
+				}
+			}
+		})
+	}
+}
+
+func TestClient_SearchEvents_ExportWithoutEvents(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	var requests []EventsSearchRequest
+	server := newFakeEventsServer(t, nil, 100, &requests)
+	export := EventsExport{Path: t.TempDir(), Manifest: true}
+	item, err := newTestClient(server.URL, "token").SearchEvents(context.Background(), "group", 7, "search", "*",
+		start.Unix(), start.Add(time.Hour).Unix(), export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(item.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "first_event_at") || strings.Contains(string(b), "last_event_at") {
+		t.Errorf("expected the dates of the events to be omitted in the manifest of an empty file:\n%s", b)
+	}
+}
+
+func TestClient_SearchEvents_OverwriteRotatedFiles(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	var requests []EventsSearchRequest
+	server := newFakeEventsServer(t, eventsEveryMinute(start, 25), 100, &requests)
+	dir := t.TempDir()
+	export := EventsExport{Path: dir, FilenameTemplate: "events.log", MaxFileEvents: 10, Mode: FileModeOverwrite}
+	client := newTestClient(server.URL, "token")
+	if _, err := client.SearchEvents(context.Background(), "group", 7, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export); err != nil {
+		t.Fatal(err)
+	}
+	// The second search splits its events in fewer files, so the last file of the first one is removed
+	export.MaxFileEvents = 20
+	item, err := client.SearchEvents(context.Background(), "group", 7, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.EventCount != 25 {
+		t.Errorf("expected 25 events, obtained %d", item.EventCount)
+	}
+	for _, name := range []string{"events.log.0001", "events.log.0002"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected the file %s to be written: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "events.log.0003")); !os.IsNotExist(err) {
+		t.Errorf("expected the file events.log.0003 of the previous search to be removed, obtained %v", err)
+	}
+}
+
+func TestEventsExport_Check(t *testing.T) {
+	if err := (EventsExport{Compression: "bzip2"}).check(); err == nil {
+		t.Error("expected an error for an unknown compression")
+	}
+	if err := (EventsExport{MaxFileEvents: -1}).check(); err == nil {
+		t.Error("expected an error for a negative number of events")
+	}
+}
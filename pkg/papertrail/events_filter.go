@@ -0,0 +1,186 @@
+package papertrail
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+// EventsFilter is the structure used to select, once they have been obtained from papertrail, the log events
+// that are written. It allows to narrow the events of a broad query or saved search without changing it
+type EventsFilter struct {
+
+	// Codes of the severities of the events selected, all of them if empty
+	severities map[int]bool
+
+	// Codes of the facilities of the events selected, all of them if empty, and of the ones discarded
+	facilities        map[int]bool
+	excludeFacilities map[int]bool
+
+	// Glob patterns of the programs of the events selected, all of them if empty, and of the ones discarded
+	programs        []string
+	excludePrograms []string
+
+	// Glob patterns of the hosts of the events selected, all of them if empty, and of the ones discarded
+	hosts        []string
+	excludeHosts []string
+}
+
+// NewEventsFilter allows to create an EventsFilter type struct selecting the events with the severities provided,
+// either a range like 'err..emerg' or a comma separated list like 'warning,err', whose facility (e.g. local0) is
+// one of the facilities provided, whose program matches one of the glob patterns of the programs provided (e.g.
+// 'nginx' or 'php-*') and whose host (the hostname or the name in papertrail of the system) matches one of the
+// glob patterns of the hosts provided (e.g. 'web-*'). The events matching the facilities, programs or hosts to
+// exclude are discarded. Every condition not provided selects all the events
+func NewEventsFilter(severity string, facilities []string, programs []string, hosts []string,
+	excludeFacilities []string, excludePrograms []string, excludeHosts []string) (*EventsFilter, error) {
+	filter := &EventsFilter{}
+	var err error
+	if filter.severities, err = parseSeverities(severity); err != nil {
+		return nil, err
+	}
+	if filter.facilities, err = parseFacilities(facilities); err != nil {
+		return nil, err
+	}
+	if filter.excludeFacilities, err = parseFacilities(excludeFacilities); err != nil {
+		return nil, err
+	}
+	if filter.programs, err = parseGlobPatterns(programs); err != nil {
+		return nil, err
+	}
+	if filter.excludePrograms, err = parseGlobPatterns(excludePrograms); err != nil {
+		return nil, err
+	}
+	if filter.hosts, err = parseGlobPatterns(hosts); err != nil {
+		return nil, err
+	}
+	if filter.excludeHosts, err = parseGlobPatterns(excludeHosts); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// Match returns if the event provided is selected by the filter. A nil filter selects all the events
+func (f *EventsFilter) Match(event Events) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.severities) > 0 {
+		severity, ok := syslogSeverities[strings.ToLower(strings.TrimSpace(event.Severity))]
+		if !ok || !f.severities[severity] {
+			return false
+		}
+	}
+	facility, knownFacility := syslogFacilities[strings.ToLower(strings.TrimSpace(event.Facility))]
+	if len(f.facilities) > 0 && (!knownFacility || !f.facilities[facility]) {
+		return false
+	}
+	if knownFacility && f.excludeFacilities[facility] {
+		return false
+	}
+	program, _ := splitSyslogProgram(event.Program)
+	programs := []string{event.Program, program}
+	if len(f.programs) > 0 && !matchGlobPatterns(f.programs, programs) {
+		return false
+	}
+	if matchGlobPatterns(f.excludePrograms, programs) {
+		return false
+	}
+	hosts := []string{event.Hostname, event.SourceName}
+	if len(f.hosts) > 0 && !matchGlobPatterns(f.hosts, hosts) {
+		return false
+	}
+	return !matchGlobPatterns(f.excludeHosts, hosts)
+}
+
+// parseSeverities returns the codes of the severities provided, either a range between two severities in
+// any order like 'err..emerg' or a comma separated list of severities
+func parseSeverities(severity string) (map[int]bool, error) {
+	if len(strings.TrimSpace(severity)) == 0 {
+		return nil, nil
+	}
+	severities := map[int]bool{}
+	if bounds := strings.Split(severity, ".."); len(bounds) == 2 {
+		from, err := severityCode(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		to, err := severityCode(bounds[1])
+		if err != nil {
+			return nil, err
+		}
+		if from > to {
+			from, to = to, from
+		}
+		for code := from; code <= to; code++ {
+			severities[code] = true
+		}
+		return severities, nil
+	}
+	for _, name := range strings.Split(severity, ",") {
+		code, err := severityCode(name)
+		if err != nil {
+			return nil, err
+		}
+		severities[code] = true
+	}
+	return severities, nil
+}
+
+// severityCode returns the code of the severity with the name provided
+func severityCode(name string) (int, error) {
+	code, ok := syslogSeverities[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return 0, errors.New("Not valid option provided for severity " + name + ", the only valid values are: " +
+			"emerg, alert, crit, err, warning, notice, info or debug, or a range like err..emerg ")
+	}
+	return code, nil
+}
+
+// parseFacilities returns the codes of the facilities with the names provided
+func parseFacilities(names []string) (map[int]bool, error) {
+	facilities := map[int]bool{}
+	for _, name := range names {
+		if len(strings.TrimSpace(name)) == 0 {
+			continue
+		}
+		code, ok := syslogFacilities[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, errors.New("Not valid option provided for facility " + name + ", the only valid values are: " +
+				"kern, user, mail, daemon, auth, syslog, lpr, news, uucp, cron, authpriv, ftp, ntp, audit, alert, cron2 or local0 to local7 ")
+		}
+		facilities[code] = true
+	}
+	return facilities, nil
+}
+
+// parseGlobPatterns returns the glob patterns provided in lower case, checking that they are valid
+func parseGlobPatterns(patterns []string) ([]string, error) {
+	var globs []string
+	for _, pattern := range patterns {
+		glob := strings.ToLower(strings.TrimSpace(pattern))
+		if len(glob) == 0 {
+			continue
+		}
+		if _, err := path.Match(glob, ""); err != nil {
+			return nil, errors.New("Error: the pattern " + pattern + " is not valid ")
+		}
+		globs = append(globs, glob)
+	}
+	return globs, nil
+}
+
+// matchGlobPatterns returns if any of the values provided matches any of the glob patterns, ignoring the case
+func matchGlobPatterns(patterns []string, values []string) bool {
+	for _, value := range values {
+		if len(value) == 0 {
+			continue
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, strings.ToLower(value)); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package papertrail
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEventsFilter_Match(t *testing.T) {
+	events := []Events{
+		{ID: "1", Severity: "Error", Facility: "Local0", Program: "nginx", Hostname: "web-1.example.com", SourceName: "web-1"},
+		{ID: "2", Severity: "Info", Facility: "Local0", Program: "nginx", Hostname: "web-2.example.com", SourceName: "web-2"},
+		{ID: "3", Severity: "Critical", Facility: "Cron", Program: "CRON[1234]", Hostname: "db-1.example.com", SourceName: "db-1"},
+		{ID: "4", Severity: "Warning", Facility: "Kernel", Program: "kernel", Hostname: "", SourceName: "web-3"},
+	}
+	tests := []struct {
+		name                            string
+		severity                        string
+		facilities, programs, hosts     []string
+		excludeFacilities, excludeHosts []string
+		excludePrograms                 []string
+		expected                        string
+	}{
+		{name: "none", expected: "1234"},
+		{name: "severity range", severity: "err..emerg", expected: "13"},
+		{name: "severity range reversed", severity: "emerg..warning", expected: "134"},
+		{name: "severity list", severity: "info, warning", expected: "24"},
+		{name: "facility", facilities: []string{"local0"}, expected: "12"},
+		{name: "exclude facility", excludeFacilities: []string{"kern"}, expected: "123"},
+		{name: "program", programs: []string{"cron"}, expected: "3"},
+		{name: "program glob", programs: []string{"ng*", "kern?l"}, expected: "124"},
+		{name: "exclude program", excludePrograms: []string{"cron"}, expected: "124"},
+		{name: "host", hosts: []string{"web-*"}, expected: "124"},
+		{name: "host and exclude host", hosts: []string{"web-*"}, excludeHosts: []string{"*.example.com"}, expected: "4"},
+		{name: "combined", severity: "warning..emerg", hosts: []string{"web-*"}, expected: "14"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter, err := NewEventsFilter(test.severity, test.facilities, test.programs, test.hosts,
+				test.excludeFacilities, test.excludePrograms, test.excludeHosts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			obtained := ""
+			for _, event := range events {
+				if filter.Match(event) {
+					obtained += event.ID
+				}
+			}
+			if obtained != test.expected {
+				t.Errorf("expected the events %s, obtained %s", test.expected, obtained)
+			}
+		})
+	}
+}
+
+func TestNewEventsFilter_Invalid(t *testing.T) {
+	if _, err := NewEventsFilter("err..fatal", nil, nil, nil, nil, nil, nil); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+	if _, err := NewEventsFilter("", []string{"local9"}, nil, nil, nil, nil, nil); err == nil {
+		t.Error("expected an error for an unknown facility")
+	}
+	if _, err := NewEventsFilter("", nil, nil, []string{"web-["}, nil, nil, nil); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestClient_SearchEvents_Filter(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	events := eventsEveryMinute(start, 6)
+	for i := range events {
+		events[i].Program = "nginx"
+		if i%2 == 0 {
+			events[i].Program = "cron"
+		}
+	}
+	var requests []EventsSearchRequest
+	server := newFakeEventsServer(t, events, 4, &requests)
+	dir, err := ioutil.TempDir("", "events-filter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	export := NewEventsExport(dir, nil)
+	if export.Filter, err = NewEventsFilter("", nil, nil, nil, nil, []string{"cron"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	item, err := newTestClient(server.URL, "token").SearchEvents(context.Background(), "group", 7, "search", "*",
+		start.Unix(), start.Add(time.Hour).Unix(), *export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(item.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "event 2\nevent 4\nevent 6\n"; item.EventCount != 3 || string(content) != expected {
+		t.Errorf("expected 3 events %q, obtained %d events %q", expected, item.EventCount, string(content))
+	}
+}
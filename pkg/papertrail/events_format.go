@@ -0,0 +1,278 @@
+package papertrail
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Formats available to write the log events obtained from papertrail
+const (
+	EventsFormatRaw        = "raw"
+	EventsFormatPapertrail = "papertrail"
+	EventsFormatJsonLines  = "jsonl"
+	EventsFormatCsv        = "csv"
+	EventsFormatLogfmt     = "logfmt"
+	EventsFormatTemplate   = "template"
+)
+
+// EventsFormatter is the interface used to write the log events obtained from papertrail
+type EventsFormatter interface {
+
+	// WriteHeader writes the text preceding the events, if the format has one
+	WriteHeader(w io.Writer) error
+
+	// WriteEvent writes an event followed by a new line
+	WriteEvent(w io.Writer, event Events) error
+}
+
+// eventField is the structure used to represent a field of the log events that can be written
+type eventField struct {
+
+	// Name of the field, the same used by papertrail in JSON
+	name string
+
+	// Function returning the value of the field for an event as text
+	value func(event Events) string
+}
+
+// eventFields are all the fields of the log events that can be written, in their default order
+var eventFields = []eventField{
+	{"id", func(event Events) string { return event.ID }},
+	{"received_at", func(event Events) string { return formatEventTime(event.ReceivedAt) }},
+	{"generated_at", func(event Events) string { return formatEventTime(event.GeneratedAt) }},
+	{"display_received_at", func(event Events) string { return event.DisplayReceivedAt }},
+	{"source_id", func(event Events) string { return strconv.FormatInt(event.SourceID, 10) }},
+	{"source_name", func(event Events) string { return event.SourceName }},
+	{"source_ip", func(event Events) string { return event.SourceIP }},
+	{"hostname", func(event Events) string { return event.Hostname }},
+	{"program", func(event Events) string { return event.Program }},
+	{"severity", func(event Events) string { return event.Severity }},
+	{"facility", func(event Events) string { return event.Facility }},
+	{"message", func(event Events) string { return event.Message }},
+}
+
+// NewEventsFormatter returns the formatter for the format provided. The columns select which fields
+// of the events and in which order are written in csv and logfmt formats, all of them when none are
+// provided. The text of the template is a Go template executed with each event in template format
+func NewEventsFormatter(format string, columns []string, text string) (EventsFormatter, error) {
+	switch strings.ToLower(format) {
+	case "", EventsFormatRaw:
+		return rawFormatter{}, nil
+	case EventsFormatPapertrail:
+		return papertrailFormatter{}, nil
+	case EventsFormatJsonLines:
+		return jsonLinesFormatter{}, nil
+	case EventsFormatRfc5424:
+		return rfc5424Formatter{}, nil
+	case EventsFormatRfc3164:
+		return rfc3164Formatter{}, nil
+	case EventsFormatCsv, EventsFormatLogfmt:
+		fields, err := selectEventFields(columns)
+		if err != nil {
+			return nil, err
+		}
+		if strings.ToLower(format) == EventsFormatCsv {
+			return csvFormatter{fields: fields}, nil
+		}
+		return logfmtFormatter{fields: fields}, nil
+	case EventsFormatTemplate:
+		if len(strings.TrimSpace(text)) == 0 {
+			return nil, errors.New("Error: a template must be provided for the template format ")
+		}
+		tmpl, err := template.New("event").Parse(text)
+		if err != nil {
+			return nil, err
+		}
+		return templateFormatter{tmpl: tmpl}, nil
+	}
+	return nil, errors.New("Not valid option provided for format, the only valid values are: " +
+		"raw, papertrail, jsonl, csv, logfmt, template, rfc5424 or rfc3164 ")
+}
+
+// selectEventFields returns the fields of the events with the names provided,
+// or all of them if no names are provided
+func selectEventFields(names []string) ([]eventField, error) {
+	if len(names) == 0 {
+		return eventFields, nil
+	}
+	var fields []eventField
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		found := false
+		for _, field := range eventFields {
+			if field.name == name {
+				fields = append(fields, field)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, errors.New("Not valid column " + name + ", the only valid values are: " + eventFieldNames())
+		}
+	}
+	return fields, nil
+}
+
+// eventFieldNames returns the names of all the fields of the events separated by commas
+func eventFieldNames() string {
+	var names []string
+	for _, field := range eventFields {
+		names = append(names, field.name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// formatEventTime returns the time provided in RFC 3339 format, or an empty string if it is not set
+func formatEventTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// rawFormatter writes only the message of the events
+type rawFormatter struct{}
+
+func (rawFormatter) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (rawFormatter) WriteEvent(w io.Writer, event Events) error {
+	_, err := io.WriteString(w, event.Message+"\n")
+	return err
+}
+
+// papertrailFormatter writes the events like the papertrail's event viewer,
+// with the date, the system and the program preceding the message
+type papertrailFormatter struct{}
+
+func (papertrailFormatter) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (papertrailFormatter) WriteEvent(w io.Writer, event Events) error {
+	date := event.DisplayReceivedAt
+	if len(date) == 0 {
+		date = event.ReceivedAt.Format("Jan 02 15:04:05")
+	}
+	host := event.SourceName
+	if len(host) == 0 {
+		host = event.Hostname
+	}
+	_, err := io.WriteString(w, date+" "+host+" "+event.Program+": "+event.Message+"\n")
+	return err
+}
+
+// jsonLinesFormatter writes each event with all its fields as a JSON object in a line
+type jsonLinesFormatter struct{}
+
+func (jsonLinesFormatter) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (jsonLinesFormatter) WriteEvent(w io.Writer, event Events) error {
+	return json.NewEncoder(w).Encode(event)
+}
+
+// csvFormatter writes the fields selected of the events as CSV records preceded by a header
+type csvFormatter struct {
+	fields []eventField
+}
+
+func (f csvFormatter) WriteHeader(w io.Writer) error {
+	var names []string
+	for _, field := range f.fields {
+		names = append(names, field.name)
+	}
+	return f.write(w, names)
+}
+
+func (f csvFormatter) WriteEvent(w io.Writer, event Events) error {
+	var values []string
+	for _, field := range f.fields {
+		values = append(values, field.value(event))
+	}
+	return f.write(w, values)
+}
+
+// write writes a CSV record with the values provided
+func (f csvFormatter) write(w io.Writer, values []string) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(values); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// logfmtFormatter writes the fields selected of the events as key=value pairs in a line
+type logfmtFormatter struct {
+	fields []eventField
+}
+
+func (logfmtFormatter) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (f logfmtFormatter) WriteEvent(w io.Writer, event Events) error {
+	var pairs []string
+	for _, field := range f.fields {
+		value := field.value(event)
+		if len(value) == 0 || strings.ContainsAny(value, " =\"\\\t\r\n") {
+			value = strconv.Quote(value)
+		}
+		pairs = append(pairs, field.name+"="+value)
+	}
+	_, err := io.WriteString(w, strings.Join(pairs, " ")+"\n")
+	return err
+}
+
+// templateFormatter writes the events executing a template with each one of them
+type templateFormatter struct {
+	tmpl *template.Template
+}
+
+func (templateFormatter) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (f templateFormatter) WriteEvent(w io.Writer, event Events) error {
+	if err := f.tmpl.Execute(w, event); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// timeZoneFormatter writes the events with other formatter after converting their times to a location
+type timeZoneFormatter struct {
+	formatter EventsFormatter
+	loc       *time.Location
+}
+
+// NewTimeZoneFormatter returns a formatter that writes the events with the formatter provided displaying their
+// times in the location provided, instead of the time zone of the papertrail's account used by display_received_at
+func NewTimeZoneFormatter(formatter EventsFormatter, loc *time.Location) EventsFormatter {
+	return timeZoneFormatter{formatter: formatter, loc: loc}
+}
+
+func (f timeZoneFormatter) WriteHeader(w io.Writer) error {
+	return f.formatter.WriteHeader(w)
+}
+
+func (f timeZoneFormatter) WriteEvent(w io.Writer, event Events) error {
+	if !event.ReceivedAt.IsZero() {
+		event.ReceivedAt = event.ReceivedAt.In(f.loc)
+		event.DisplayReceivedAt = event.ReceivedAt.Format("Jan 02 15:04:05")
+	}
+	if !event.GeneratedAt.IsZero() {
+		event.GeneratedAt = event.GeneratedAt.In(f.loc)
+	}
+	return f.formatter.WriteEvent(w, event)
+}
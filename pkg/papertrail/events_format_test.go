@@ -0,0 +1,124 @@
+package papertrail
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestEventsFormatter(t *testing.T) {
+	event := Events{
+		ID:                "1234567890",
+		SourceIP:          "10.0.0.2",
+		Program:           "nginx",
+		Message:           `GET /index.html "200"`,
+		ReceivedAt:        time.Date(2020, 5, 4, 16, 44, 53, 0, time.UTC),
+		DisplayReceivedAt: "May 04 16:44:53",
+		SourceID:          31,
+		SourceName:        "web-1",
+		Hostname:          "web-1.example.com",
+		Severity:          "Info",
+		Facility:          "Local0",
+	}
+	tests := []struct {
+		name     string
+		format   string
+		columns  []string
+		template string
+		expected string
+	}{
+		{
+			name:     "raw",
+			format:   EventsFormatRaw,
+			expected: "GET /index.html \"200\"\n",
+		},
+		{
+			name:     "papertrail",
+			format:   EventsFormatPapertrail,
+			expected: "May 04 16:44:53 web-1 nginx: GET /index.html \"200\"\n",
+		},
+		{
+			name:   "jsonl",
+			format: EventsFormatJsonLines,
+			expected: `{"id":"1234567890","source_ip":"10.0.0.2","program":"nginx","message":"GET /index.html \"200\"",` +
+				`"received_at":"2020-05-04T16:44:53Z","generated_at":"0001-01-01T00:00:00Z",` +
+				`"display_received_at":"May 04 16:44:53","source_id":31,"source_name":"web-1",` +
+				`"hostname":"web-1.example.com","severity":"Info","facility":"Local0"}` + "\n",
+		},
+		{
+			name:     "csv with columns",
+			format:   EventsFormatCsv,
+			columns:  []string{"received_at", "hostname", "message"},
+			expected: "received_at,hostname,message\n2020-05-04T16:44:53Z,web-1.example.com,\"GET /index.html \"\"200\"\"\"\n",
+		},
+		{
+			name:     "logfmt with columns",
+			format:   EventsFormatLogfmt,
+			columns:  []string{"source_name", "generated_at", "severity", "message"},
+			expected: "source_name=web-1 generated_at=\"\" severity=Info message=\"GET /index.html \\\"200\\\"\"\n",
+		},
+		{
+			name:     "template",
+			format:   EventsFormatTemplate,
+			template: "{{.Hostname}} [{{.Severity}}] {{.Message}}",
+			expected: "web-1.example.com [Info] GET /index.html \"200\"\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formatter, err := NewEventsFormatter(test.format, test.columns, test.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output bytes.Buffer
+			if err := formatter.WriteHeader(&output); err != nil {
+				t.Fatal(err)
+			}
+			if err := formatter.WriteEvent(&output, event); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != test.expected {
+				t.Errorf("expected output:\n%q\nobtained:\n%q", test.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestNewEventsFormatter_Invalid(t *testing.T) {
+	if _, err := NewEventsFormatter("xml", nil, ""); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+	if _, err := NewEventsFormatter(EventsFormatCsv, []string{"id", "unknown"}, ""); err == nil {
+		t.Error("expected an error for an unknown column")
+	}
+	if _, err := NewEventsFormatter(EventsFormatTemplate, nil, ""); err == nil {
+		t.Error("expected an error for a missing template")
+	}
+}
+
+func TestTimeZoneFormatter(t *testing.T) {
+	event := Events{
+		Program:           "nginx",
+		Message:           "GET /index.html",
+		ReceivedAt:        time.Date(2020, 5, 4, 16, 44, 53, 0, time.UTC),
+		DisplayReceivedAt: "May 04 18:44:53",
+		SourceName:        "web-1",
+	}
+	tests := map[string]string{
+		EventsFormatPapertrail: "May 04 12:44:53 web-1 nginx: GET /index.html\n",
+		EventsFormatLogfmt:     "received_at=2020-05-04T12:44:53-04:00 message=\"GET /index.html\"\n",
+	}
+	for format, expected := range tests {
+		formatter, err := NewEventsFormatter(format, []string{"received_at", "message"}, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var output bytes.Buffer
+		if err := NewTimeZoneFormatter(formatter, time.FixedZone("-04:00", -4*3600)).WriteEvent(&output, event); err != nil {
+			t.Fatal(err)
+		}
+		if output.String() != expected {
+			t.Errorf("expected %q in %s format, obtained %q", expected, format, output.String())
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package papertrail
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// defaultShardsConcurrency is the maximum number of shards whose events are obtained at the same time by default
+const defaultShardsConcurrency = 4
+
+// getShardsEventsSearchPages obtains the events of the shards of the checkpoint provided that are not complete,
+// in the group provided or in the system of each shard, storing the pages of each one in its spool and the
+// progress in the checkpoint after every page. The shards are obtained concurrently, at most the number provided at the same time, sharing
+// the rate limit of the client. When a shard fails the rest are cancelled
+func (c *Client) getShardsEventsSearchPages(ctx context.Context, groupId int, searchQuery string, checkpoint *eventsCheckpoint,
+	spools []*eventsSpool, concurrency int, checkpointPath string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, concurrency)
+	errs := make([]error, len(checkpoint.Shards))
+	for index, shard := range checkpoint.Shards {
+		if shard.Complete {
+			continue
+		}
+		wg.Add(1)
+		go func(index int, shard *shardCheckpoint) {
+			defer wg.Done()
+			select {
+			case workers <- struct{}{}:
+				defer func() { <-workers }()
+			case <-ctx.Done():
+				errs[index] = ctx.Err()
+				return
+			}
+			truncated, err := c.getEventsSearchPages(ctx, groupId, shard.SystemID, searchQuery, shard.MinTime, shard.MaxTime, shard.MinID,
+				func(events []Events, truncatedUntilNow bool) error {
+					if err := spools[index].addPage(events); err != nil {
+						return err
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					shard.addPage(events, spools[index])
+					checkpoint.Truncated = checkpoint.Truncated || truncatedUntilNow
+					c.logShardProgress(checkpoint, index, "events obtained")
+					return checkpoint.write(checkpointPath)
+				})
+			mu.Lock()
+			defer mu.Unlock()
+			checkpoint.Truncated = checkpoint.Truncated || truncated
+			if err == nil {
+				shard.Complete = true
+				c.logShardProgress(checkpoint, index, "events obtained, finished")
+				err = checkpoint.write(checkpointPath)
+			}
+			if err != nil {
+				errs[index] = err
+				cancel()
+			}
+		}(index, shard)
+	}
+	wg.Wait()
+	// The errors of the shards cancelled because other shard failed are ignored
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logShardProgress logs the number of events obtained by a shard, if the window is split in several shards
+func (c *Client) logShardProgress(checkpoint *eventsCheckpoint, index int, message string) {
+	if len(checkpoint.Shards) < 2 {
+		return
+	}
+	shard := checkpoint.Shards[index]
+	system := ""
+	if shard.SystemID != 0 {
+		system = "system " + strconv.FormatInt(shard.SystemID, 10) + ", "
+	}
+	c.logf("Shard %d/%d (%s%s - %s): %d %s\n", index+1, len(checkpoint.Shards), system, GetTimeInUTCFromUnixTime(shard.MinTime),
+		GetTimeInUTCFromUnixTime(shard.MaxTime), shard.EventCount, message)
+}
+
+// mergeEventsSpools calls the handler for every event stored in the spools provided in chronological order,
+// merging the events of all of them by their identifier and skipping the duplicated ones
+func mergeEventsSpools(spools []*eventsSpool, handler func(event Events) error) error {
+	readers := make([]*eventsSpoolReader, len(spools))
+	heads := make([]*Events, len(spools))
+	for index, spool := range spools {
+		readers[index] = spool.reader()
+		defer readers[index].close()
+		head, err := readers[index].next()
+		if err != nil {
+			return err
+		}
+		heads[index] = head
+	}
+	lastId := ""
+	for {
+		oldest := -1
+		for index, head := range heads {
+			if head != nil && (oldest < 0 || compareEventIds(head.ID, heads[oldest].ID) < 0) {
+				oldest = index
+			}
+		}
+		if oldest < 0 {
+			return nil
+		}
+		event := *heads[oldest]
+		head, err := readers[oldest].next()
+		if err != nil {
+			return err
+		}
+		heads[oldest] = head
+		if len(lastId) > 0 && event.ID == lastId {
+			continue
+		}
+		lastId = event.ID
+		if err := handler(event); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package papertrail
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSplitWindow(t *testing.T) {
+	tests := []struct {
+		minTime  int64
+		maxTime  int64
+		parts    int
+		expected [][2]int64
+	}{
+		{0, 99, 1, [][2]int64{{0, 99}}},
+		{0, 99, 4, [][2]int64{{0, 24}, {25, 49}, {50, 74}, {75, 99}}},
+		{10, 20, 3, [][2]int64{{10, 12}, {13, 16}, {17, 20}}},
+		{10, 11, 5, [][2]int64{{10, 10}, {11, 11}}},
+	}
+	for _, test := range tests {
+		if windows := splitWindow(test.minTime, test.maxTime, test.parts); !reflect.DeepEqual(windows, test.expected) {
+			t.Errorf("expected windows %v splitting [%d, %d] in %d, obtained %v", test.expected, test.minTime,
+				test.maxTime, test.parts, windows)
+		}
+	}
+}
+
+func TestMergeEventsSpools(t *testing.T) {
+	dir, err := ioutil.TempDir("", "events-merge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Each spool receives its pages from the newest to the oldest, the event 4 is in both spools
+	pages := [][][]Events{
+		{{{ID: "4"}, {ID: "6"}}, {{ID: "1"}, {ID: "3"}}},
+		{{{ID: "7"}}, {{ID: "2"}, {ID: "4"}, {ID: "5"}}},
+		{},
+	}
+	var spools []*eventsSpool
+	for index, spoolPages := range pages {
+		spool, err := newEventsSpool(shardSpoolDir(dir, index))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, page := range spoolPages {
+			if err := spool.addPage(page); err != nil {
+				t.Fatal(err)
+			}
+		}
+		spools = append(spools, spool)
+	}
+	var ids []string
+	err = mergeEventsSpools(spools, func(event Events) error {
+		ids = append(ids, event.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"1", "2", "3", "4", "5", "6", "7"}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected events %v, obtained %v", expected, ids)
+	}
+}
+
+func TestClient_SearchEvents_Shards(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	events := eventsEveryMinute(start, 59)
+	var mu sync.Mutex
+	var requests []EventsSearchRequest
+	handler := fakeEventsHandler(events, 4, &requests)
+	// The shards are obtained concurrently, so the requests are handled one by one to record them
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		handler(w, r)
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "events-search-shards")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	export := EventsExport{Path: dir, FilenameTemplate: "events.log", Shards: 4, Concurrency: 2}
+	item, err := newTestClient(server.URL, "token").SearchEvents(context.Background(), "group", 0, "search", "*",
+		start.Unix(), start.Add(time.Hour).Unix()-1, export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.EventCount != 59 {
+		t.Errorf("expected 59 events, obtained %d", item.EventCount)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "events.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ""
+	for _, event := range events {
+		expected += event.Message + "\n"
+	}
+	if string(content) != expected {
+		t.Errorf("expected file content:\n%q\nobtained:\n%q", expected, string(content))
+	}
+	// Every request is limited to the window of one of the shards of 15 minutes
+	windows := map[string]bool{}
+	for _, request := range requests {
+		windows[request.MinTime+"-"+request.MaxTime] = true
+	}
+	if len(windows) != 4 {
+		t.Errorf("expected requests for 4 shards, obtained %v", windows)
+	}
+}
@@ -0,0 +1,143 @@
+package papertrail
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// eventsSpool stores in temporary chunk files the pages of events obtained from papertrail, which
+// are received from the newest to the oldest, so they can be written in chronological order
+// at the end without keeping all of them in memory
+type eventsSpool struct {
+
+	// Temporary directory where the chunk files are stored
+	dir string
+
+	// Paths of the chunk files, in the order in which the pages were received
+	chunks []string
+
+	// Number of events stored
+	count int
+}
+
+// newEventsSpool creates an events spool whose chunk files are stored in the directory
+// provided, which is created removing the content it could have
+func newEventsSpool(dir string) (*eventsSpool, error) {
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &eventsSpool{dir: dir}, nil
+}
+
+// addPage stores a page of events, which must be older than the events of the pages already
+// stored and must be sorted in chronological order, in a new chunk file as JSON lines
+func (s *eventsSpool) addPage(events []Events) error {
+	if len(events) == 0 {
+		return nil
+	}
+	chunkPath := filepath.Join(s.dir, "chunk-"+strconv.Itoa(len(s.chunks)))
+	file, err := os.Create(chunkPath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	s.chunks = append(s.chunks, chunkPath)
+	s.count += len(events)
+	return nil
+}
+
+// stitch calls the handler for every event stored in chronological order
+func (s *eventsSpool) stitch(handler func(event Events) error) error {
+	reader := s.reader()
+	defer reader.close()
+	for {
+		event, err := reader.next()
+		if err != nil || event == nil {
+			return err
+		}
+		if err := handler(*event); err != nil {
+			return err
+		}
+	}
+}
+
+// close removes the directory with the chunk files
+func (s *eventsSpool) close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// eventsSpoolReader reads in chronological order the events stored in a spool, reading
+// the chunk files from the last one stored, which contains the oldest events
+type eventsSpoolReader struct {
+
+	// Spool whose events are read
+	spool *eventsSpool
+
+	// Index of the chunk file being read
+	index int
+
+	// Chunk file being read and decoder of its events, nil if no chunk file is being read
+	file    *os.File
+	decoder *json.Decoder
+}
+
+// reader returns a reader of the events stored in the spool
+func (s *eventsSpool) reader() *eventsSpoolReader {
+	return &eventsSpoolReader{spool: s, index: len(s.chunks)}
+}
+
+// next returns the next event stored in the spool, or nil if all the events have been read
+func (r *eventsSpoolReader) next() (*Events, error) {
+	for {
+		if r.file == nil {
+			if r.index == 0 {
+				return nil, nil
+			}
+			r.index--
+			file, err := os.Open(r.spool.chunks[r.index])
+			if err != nil {
+				return nil, err
+			}
+			r.file = file
+			r.decoder = json.NewDecoder(bufio.NewReader(file))
+		}
+		var event Events
+		err := r.decoder.Decode(&event)
+		if err == nil {
+			return &event, nil
+		}
+		r.close()
+		if err != io.EOF {
+			return nil, err
+		}
+	}
+}
+
+// close closes the chunk file being read, if there is one
+func (r *eventsSpoolReader) close() {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
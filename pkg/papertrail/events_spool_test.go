@@ -0,0 +1,50 @@
+package papertrail
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestEventsSpool_StitchesPagesInChronologicalOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "events-spool-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	spool, err := newEventsSpool(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The pages are received from the newest to the oldest
+	pages := [][]Events{
+		{{ID: "5", Message: "fifth"}, {ID: "6", Message: "sixth"}},
+		{},
+		{{ID: "3", Message: "third\nwith a new line"}, {ID: "4", Message: "fourth"}},
+		{{ID: "1", Message: "first"}, {ID: "2", Message: "second"}},
+	}
+	for _, page := range pages {
+		if err := spool.addPage(page); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var messages []string
+	err = spool.stitch(func(event Events) error {
+		messages = append(messages, event.Message)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"first", "second", "third\nwith a new line", "fourth", "fifth", "sixth"}
+	if !reflect.DeepEqual(messages, expected) || spool.count != len(expected) {
+		t.Errorf("expected %v, obtained %v (count %d)", expected, messages, spool.count)
+	}
+	if err := spool.close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(spool.dir); !os.IsNotExist(err) {
+		t.Errorf("expected the spool directory to be removed, obtained %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package papertrail
+
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Syslog formats available to write the log events obtained from papertrail
+const (
+	EventsFormatRfc5424 = "rfc5424"
+	EventsFormatRfc3164 = "rfc3164"
+)
+
+// Facility and severity used when the ones of an event are unknown, which
+// are the ones given by RFC 3164 to the messages received without PRI
+const (
+	defaultSyslogFacility = 1
+	defaultSyslogSeverity = 5
+)
+
+// syslogFacilities are the codes of the syslog facilities by the names used by papertrail and their keywords
+var syslogFacilities = map[string]int{
+	"kern":            0,
+	"kernel":          0,
+	"user":            1,
+	"mail":            2,
+	"daemon":          3,
+	"system":          3,
+	"system daemon":   3,
+	"auth":            4,
+	"authorization":   4,
+	"security":        4,
+	"syslog":          5,
+	"lpr":             6,
+	"printer":         6,
+	"news":            7,
+	"uucp":            8,
+	"cron":            9,
+	"clock":           9,
+	"clock daemon":    9,
+	"authpriv":        10,
+	"auth2":           10,
+	"authorization 2": 10,
+	"ftp":             11,
+	"ntp":             12,
+	"audit":           13,
+	"log audit":       13,
+	"alert":           14,
+	"log alert":       14,
+	"cron2":           15,
+	"clock 2":         15,
+	"clock daemon 2":  15,
+	"local0":          16,
+	"local1":          17,
+	"local2":          18,
+	"local3":          19,
+	"local4":          20,
+	"local5":          21,
+	"local6":          22,
+	"local7":          23,
+}
+
+// syslogSeverities are the codes of the syslog severities by the names used by papertrail and their keywords
+var syslogSeverities = map[string]int{
+	"emerg":         0,
+	"emergency":     0,
+	"panic":         0,
+	"alert":         1,
+	"crit":          2,
+	"critical":      2,
+	"err":           3,
+	"error":         3,
+	"warning":       4,
+	"warn":          4,
+	"notice":        5,
+	"info":          6,
+	"informational": 6,
+	"debug":         7,
+}
+
+// syslogPriority returns the PRI value of an event, calculated from its facility and severity
+func syslogPriority(event Events) int {
+	facility, ok := syslogFacilities[strings.ToLower(strings.TrimSpace(event.Facility))]
+	if !ok {
+		facility = defaultSyslogFacility
+	}
+	severity, ok := syslogSeverities[strings.ToLower(strings.TrimSpace(event.Severity))]
+	if !ok {
+		severity = defaultSyslogSeverity
+	}
+	return facility*8 + severity
+}
+
+// syslogTime returns the time in which an event was generated, or
+// the one in which it was received if the first is not known
+func syslogTime(event Events) time.Time {
+	if !event.GeneratedAt.IsZero() {
+		return event.GeneratedAt
+	}
+	return event.ReceivedAt
+}
+
+// syslogHostname returns the hostname of the system that sent an event, or its name in papertrail if unknown
+func syslogHostname(event Events) string {
+	if len(event.Hostname) > 0 {
+		return event.Hostname
+	}
+	return event.SourceName
+}
+
+// splitSyslogProgram splits the program of an event in the name of the application
+// and the process id, which papertrail keeps together as in 'sshd[1234]'
+func splitSyslogProgram(program string) (string, string) {
+	start := strings.LastIndex(program, "[")
+	if start > 0 && strings.HasSuffix(program, "]") {
+		return program[:start], program[start+1 : len(program)-1]
+	}
+	return program, ""
+}
+
+// syslogHeaderField returns the value provided as a field of a RFC 5424 header, which only can contain
+// printable US-ASCII characters up to a maximum length, or the nil value '-' if it is empty
+func syslogHeaderField(value string, maxLength int) string {
+	field := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(field) > maxLength {
+		field = field[:maxLength]
+	}
+	if len(field) == 0 {
+		return "-"
+	}
+	return field
+}
+
+// rfc5424Formatter writes the events as syslog messages following RFC 5424
+type rfc5424Formatter struct{}
+
+func (rfc5424Formatter) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (rfc5424Formatter) WriteEvent(w io.Writer, event Events) error {
+	timestamp := "-"
+	if t := syslogTime(event); !t.IsZero() {
+		timestamp = t.Format("2006-01-02T15:04:05.999999Z07:00")
+	}
+	appName, procId := splitSyslogProgram(event.Program)
+	line := "<" + strconv.Itoa(syslogPriority(event)) + ">1 " + timestamp + " " +
+		syslogHeaderField(syslogHostname(event), 255) + " " + syslogHeaderField(appName, 48) + " " +
+		syslogHeaderField(procId, 128) + " - -"
+	if len(event.Message) > 0 {
+		line += " " + event.Message
+	}
+	_, err := io.WriteString(w, line+"\n")
+	return err
+}
+
+// rfc3164Formatter writes the events as syslog messages following RFC 3164. As its timestamp
+// doesn't include the time zone, the time of the events is written in UTC
+type rfc3164Formatter struct{}
+
+func (rfc3164Formatter) WriteHeader(w io.Writer) error {
+	return nil
+}
+
+func (rfc3164Formatter) WriteEvent(w io.Writer, event Events) error {
+	line := "<" + strconv.Itoa(syslogPriority(event)) + ">" + syslogTime(event).UTC().Format(time.Stamp) + " " +
+		strings.Replace(syslogHostname(event), " ", "_", -1) + " "
+	if len(event.Program) > 0 {
+		line += event.Program + ": "
+	}
+	_, err := io.WriteString(w, line+event.Message+"\n")
+	return err
+}
+
+// octetCountingFormatter frames the events written by other formatter preceding each one
+// with its length in bytes instead of ending it with a new line, following RFC 6587
+type octetCountingFormatter struct {
+	formatter EventsFormatter
+}
+
+// NewOctetCountingFormatter returns a formatter that frames the events written by the formatter provided
+// with octet counting, as expected by the syslog consumers over TCP, e.g. '67 <14>1 2020-05-04T16:44:53Z ...'
+func NewOctetCountingFormatter(formatter EventsFormatter) EventsFormatter {
+	return octetCountingFormatter{formatter: formatter}
+}
+
+func (f octetCountingFormatter) WriteHeader(w io.Writer) error {
+	return f.formatter.WriteHeader(w)
+}
+
+func (f octetCountingFormatter) WriteEvent(w io.Writer, event Events) error {
+	var frame bytes.Buffer
+	if err := f.formatter.WriteEvent(&frame, event); err != nil {
+		return err
+	}
+	message := bytes.TrimSuffix(frame.Bytes(), []byte("\n"))
+	if _, err := io.WriteString(w, strconv.Itoa(len(message))+" "); err != nil {
+		return err
+	}
+	_, err := w.Write(message)
+	return err
+}
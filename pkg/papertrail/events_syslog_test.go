@@ -0,0 +1,84 @@
+package papertrail
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSyslogFormatters(t *testing.T) {
+	event := Events{
+		ID:          "1234567890",
+		Program:     "sshd[4321]",
+		Message:     "Accepted publickey for deploy",
+		ReceivedAt:  time.Date(2020, 5, 4, 16, 44, 53, 0, time.UTC),
+		GeneratedAt: time.Date(2020, 5, 4, 18, 44, 52, 250000000, time.FixedZone("CEST", 2*60*60)),
+		SourceName:  "web 1",
+		Severity:    "Info",
+		Facility:    "Auth",
+	}
+	tests := []struct {
+		name     string
+		format   string
+		event    Events
+		expected string
+	}{
+		{
+			name:     "rfc5424",
+			format:   EventsFormatRfc5424,
+			event:    event,
+			expected: "<38>1 2020-05-04T18:44:52.25+02:00 web_1 sshd 4321 - - Accepted publickey for deploy\n",
+		},
+		{
+			name:     "rfc5424 without optional fields",
+			format:   EventsFormatRfc5424,
+			event:    Events{Severity: "Error", Facility: "Local7"},
+			expected: "<187>1 - - - - - -\n",
+		},
+		{
+			name:     "rfc3164",
+			format:   EventsFormatRfc3164,
+			event:    event,
+			expected: "<38>May  4 16:44:52 web_1 sshd[4321]: Accepted publickey for deploy\n",
+		},
+		{
+			name:     "rfc3164 with unknown facility and severity",
+			format:   EventsFormatRfc3164,
+			event:    Events{Message: "message", ReceivedAt: event.ReceivedAt, Hostname: "db-1", Facility: "unknown"},
+			expected: "<13>May  4 16:44:53 db-1 message\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			formatter, err := NewEventsFormatter(test.format, nil, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var output bytes.Buffer
+			if err := formatter.WriteEvent(&output, test.event); err != nil {
+				t.Fatal(err)
+			}
+			if output.String() != test.expected {
+				t.Errorf("expected output:\n%q\nobtained:\n%q", test.expected, output.String())
+			}
+		})
+	}
+}
+
+func TestOctetCountingFormatter(t *testing.T) {
+	formatter, err := NewEventsFormatter(EventsFormatRfc5424, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter = NewOctetCountingFormatter(formatter)
+	var output bytes.Buffer
+	for _, message := range []string{"first", "second\nline"} {
+		if err := formatter.WriteEvent(&output, Events{Message: message}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := "23 <13>1 - - - - - - first29 <13>1 - - - - - - second\nline"
+	if output.String() != expected {
+		t.Errorf("expected output:\n%q\nobtained:\n%q", expected, output.String())
+	}
+}
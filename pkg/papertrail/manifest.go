@@ -0,0 +1,166 @@
+package papertrail
+
+import (
+	"context"
+	"errors"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// Manifest is the structure used to declare the systems, groups and saved searches of a papertrail
+// account, so the account can be managed as code applying the changes needed to make it match
+type Manifest struct {
+	Systems  []ManifestSystem `json:"systems" yaml:"systems"`
+	Groups   []ManifestGroup  `json:"groups" yaml:"groups"`
+	Searches []ManifestSearch `json:"searches" yaml:"searches"`
+}
+
+// ManifestSystem is the structure used to declare a system in a manifest, either based in hostname, sending
+// its logs to a destination given by its port or its identifier, or based in the IP address of the system
+type ManifestSystem struct {
+	Name            string `json:"name" yaml:"name"`
+	Hostname        string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	IPAddress       string `json:"ip_address,omitempty" yaml:"ip_address,omitempty"`
+	DestinationPort int    `json:"destination_port,omitempty" yaml:"destination_port,omitempty"`
+	DestinationID   int    `json:"destination_id,omitempty" yaml:"destination_id,omitempty"`
+}
+
+// ManifestGroup is the structure used to declare a group in a manifest
+type ManifestGroup struct {
+	Name           string `json:"name" yaml:"name"`
+	SystemWildcard string `json:"system_wildcard" yaml:"system_wildcard"`
+}
+
+// ManifestSearch is the structure used to declare a saved search in a manifest, identified by its name and group
+type ManifestSearch struct {
+	Name  string `json:"name" yaml:"name"`
+	Group string `json:"group" yaml:"group"`
+	Query string `json:"query" yaml:"query"`
+}
+
+// ReadManifest reads the manifest stored in the file with the path provided, in YAML or JSON format,
+// checking that it is valid. The fields unknown are rejected, so typos are not silently ignored
+func ReadManifest(pathFileName string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(pathFileName)
+	if err != nil {
+		return nil, err
+	}
+	var manifest Manifest
+	if err := yaml.UnmarshalStrict(b, &manifest); err != nil {
+		return nil, errors.New("Error: the manifest " + pathFileName + " is not valid: " + err.Error() + " ")
+	}
+	if err := manifest.Check(); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// Check checks that the elements declared in the manifest are valid and that there aren't
+// several systems or groups with the same name, or several searches with the same name in a group
+func (m *Manifest) Check() error {
+	systems := map[string]bool{}
+	for _, system := range m.Systems {
+		if err := system.check(); err != nil {
+			return err
+		}
+		if systems[system.Name] {
+			return errors.New("Error: the system " + system.Name + " is declared several times in the manifest ")
+		}
+		systems[system.Name] = true
+	}
+	groups := map[string]bool{}
+	for _, group := range m.Groups {
+		if len(strings.TrimSpace(group.Name)) == 0 {
+			return errors.New("Error: it's necessary to provide the name of all the groups of the manifest ")
+		}
+		if groups[group.Name] {
+			return errors.New("Error: the group " + group.Name + " is declared several times in the manifest ")
+		}
+		groups[group.Name] = true
+	}
+	searches := map[string]bool{}
+	for _, search := range m.Searches {
+		if len(strings.TrimSpace(search.Name)) == 0 || len(strings.TrimSpace(search.Group)) == 0 {
+			return errors.New("Error: it's necessary to provide the name and the group of all the searches of the manifest ")
+		}
+		if searches[search.key()] {
+			return errors.New("Error: the search " + search.Name + " of the group " + search.Group +
+				" is declared several times in the manifest ")
+		}
+		searches[search.key()] = true
+	}
+	return nil
+}
+
+// check checks that the system declares its name and either a hostname with a destination port
+// or identifier, or an IP address
+func (s ManifestSystem) check() error {
+	if len(strings.TrimSpace(s.Name)) == 0 {
+		return errors.New("Error: it's necessary to provide the name of all the systems of the manifest ")
+	}
+	if len(s.Hostname) > 0 == (len(s.IPAddress) > 0) {
+		return errors.New("Error: it's necessary to provide either hostname or ip address for the system " + s.Name + " ")
+	}
+	if len(s.Hostname) > 0 && (s.DestinationPort != 0) == (s.DestinationID != 0) {
+		return errors.New("Error: it's necessary to provide either destination id or destination port for the system " +
+			s.Name + " ")
+	}
+	if len(s.IPAddress) > 0 && (s.DestinationPort != 0 || s.DestinationID != 0) {
+		return errors.New("Error: the system " + s.Name + " based in ip address can't have a destination ")
+	}
+	return nil
+}
+
+// key returns the identifier of the search in the manifest, formed by its group and its name
+func (s ManifestSearch) key() string {
+	return searchKey(s.Group, s.Name)
+}
+
+// searchKey returns the identifier of a search formed by the name of its group and its name
+func searchKey(groupName string, searchName string) string {
+	return groupName + "/" + searchName
+}
+
+// ExportManifest reads the systems, groups and saved searches of the account and returns the manifest declaring
+// them, so it can be applied to reproduce the account. The elements are sorted by their names, and the searches by
+// the name of their group first, so the same account is always exported in the same way. The systems with an IP
+// address are declared by it, and the rest by their hostname and the port of their destination
+func (c *Client) ExportManifest(ctx context.Context) (*Manifest, error) {
+	state, err := c.getAccountState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{Systems: []ManifestSystem{}, Groups: []ManifestGroup{}, Searches: []ManifestSearch{}}
+	ports := map[int]bool{}
+	for _, destination := range state.destinations {
+		ports[destination.Syslog.Port] = true
+	}
+	for _, system := range state.systems {
+		declared := manifestSystemFromSystem(system)
+		if len(declared.Hostname) > 0 && !ports[declared.DestinationPort] {
+			c.logf("Warning: the port %d of the system %s doesn't belong to any destination of the account\n",
+				declared.DestinationPort, system.Name)
+		}
+		manifest.Systems = append(manifest.Systems, declared)
+	}
+	for _, group := range state.groups {
+		manifest.Groups = append(manifest.Groups, ManifestGroup{Name: group.Name, SystemWildcard: group.SystemWildcard})
+	}
+	for _, search := range state.searches {
+		manifest.Searches = append(manifest.Searches, ManifestSearch{Name: search.Name, Group: search.Group.Name, Query: search.Query})
+	}
+	sort.SliceStable(manifest.Systems, func(i, j int) bool { return manifest.Systems[i].Name < manifest.Systems[j].Name })
+	sort.SliceStable(manifest.Groups, func(i, j int) bool { return manifest.Groups[i].Name < manifest.Groups[j].Name })
+	sort.SliceStable(manifest.Searches, func(i, j int) bool {
+		if manifest.Searches[i].Group != manifest.Searches[j].Group {
+			return manifest.Searches[i].Group < manifest.Searches[j].Group
+		}
+		return manifest.Searches[i].Name < manifest.Searches[j].Name
+	})
+	if err := manifest.Check(); err != nil {
+		return nil, errors.New("Error: the account can't be exported as a manifest, " + strings.TrimPrefix(err.Error(), "Error: "))
+	}
+	return manifest, nil
+}
@@ -0,0 +1,57 @@
+package papertrail
+
+import "context"
+
+// Kinds of the differences between a manifest and an account
+const (
+	DriftMissing   = "missing"
+	DriftChanged   = "changed"
+	DriftUnmanaged = "unmanaged"
+)
+
+// DriftDifference is the structure used to represent a difference between an element declared in a manifest
+// and the account: an element declared that doesn't exist, an element whose fields have been changed, or an
+// element that exists in the account but is not declared in the manifest
+type DriftDifference struct {
+	Kind     string `json:"kind"`
+	ItemType string `json:"type"`
+	Name     string `json:"name"`
+
+	// Name of the group of the search, only for searches
+	Group string `json:"group,omitempty"`
+
+	// Identifier of the element in papertrail, 0 if it doesn't exist
+	ID int64 `json:"id,omitempty"`
+
+	// Fields whose values differ, the value in the account as before and the declared as after
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// DetectDrift compares the manifest provided with the account field by field, returning the differences
+// found, which are empty if the account matches the manifest. The elements are identified in the same
+// way as when the manifest is applied, and the differences are returned in the same order
+func (c *Client) DetectDrift(ctx context.Context, manifest *Manifest) ([]DriftDifference, error) {
+	if err := manifest.Check(); err != nil {
+		return nil, err
+	}
+	state, err := c.getAccountState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planManifest(manifest, state, false)
+	if err != nil {
+		return nil, err
+	}
+	plan.addDeletes(state, manifest)
+	kinds := map[string]string{PlanActionCreate: DriftMissing, PlanActionUpdate: DriftChanged, PlanActionDelete: DriftUnmanaged}
+	differences := []DriftDifference{}
+	for _, change := range plan.Changes {
+		difference := DriftDifference{Kind: kinds[change.Action], ItemType: change.ItemType, Name: change.Name,
+			Group: change.Group, ID: change.ID}
+		if change.Action == PlanActionUpdate {
+			difference.Fields = change.Fields
+		}
+		differences = append(differences, difference)
+	}
+	return differences, nil
+}
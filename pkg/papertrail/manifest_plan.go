@@ -0,0 +1,373 @@
+package papertrail
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+// Actions of the changes of a plan
+const (
+	PlanActionCreate = "create"
+	PlanActionUpdate = "update"
+	PlanActionDelete = "delete"
+)
+
+// FieldChange is the structure used to represent the change of a field of an element of papertrail
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// PlanChange is the structure used to represent one of the changes needed to make an account match a manifest
+type PlanChange struct {
+	Action   string `json:"action"`
+	ItemType string `json:"type"`
+	Name     string `json:"name"`
+
+	// Name of the group of the search, only for searches
+	Group string `json:"group,omitempty"`
+
+	// Identifier of the element in papertrail, 0 if it has to be created
+	ID int64 `json:"id,omitempty"`
+
+	// Fields whose value changes, all the fields declared when the element is created
+	Fields []FieldChange `json:"fields,omitempty"`
+
+	// Reason why the change can't be applied, empty if it can be applied
+	Unsupported string `json:"unsupported,omitempty"`
+
+	// Element of the manifest to be created or updated
+	system *ManifestSystem
+	group  *ManifestGroup
+	search *ManifestSearch
+}
+
+// Plan is the structure used to represent the changes needed to make an account match a manifest,
+// in the order in which they are applied
+type Plan struct {
+	Changes []PlanChange `json:"changes"`
+
+	// Identifiers of the groups existing in papertrail by their names
+	groupIds map[string]int
+}
+
+// accountState is the structure used to represent the elements existing in a papertrail account
+type accountState struct {
+	systems      []System
+	groups       []GroupObject
+	searches     []SearchObject
+	destinations []Destination
+}
+
+// getAccountState obtains all the systems, groups, saved searches and destinations of the account
+func (c *Client) getAccountState(ctx context.Context) (*accountState, error) {
+	var state accountState
+	var err error
+	if state.systems, err = c.ListSystems(ctx); err != nil {
+		return nil, err
+	}
+	if state.groups, err = c.ListGroups(ctx); err != nil {
+		return nil, err
+	}
+	if state.searches, err = c.ListSearches(ctx); err != nil {
+		return nil, err
+	}
+	if state.destinations, err = c.ListDestinations(ctx); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// PlanManifest compares the manifest provided with the account, returning the changes needed to make the account
+// match it: the elements declared that don't exist are created and the ones whose fields differ are updated. The
+// systems, groups and searches are identified by their names (and their group for the searches). When prune is
+// true the elements not declared in the manifest are deleted, otherwise they are left as they are
+func (c *Client) PlanManifest(ctx context.Context, manifest *Manifest, prune bool) (*Plan, error) {
+	if err := manifest.Check(); err != nil {
+		return nil, err
+	}
+	state, err := c.getAccountState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return planManifest(manifest, state, prune)
+}
+
+// planManifest returns the changes needed to make the account with the state provided match the manifest
+func planManifest(manifest *Manifest, state *accountState, prune bool) (*Plan, error) {
+	plan := &Plan{groupIds: map[string]int{}}
+	systems := map[string]System{}
+	for _, system := range state.systems {
+		if _, found := systems[system.Name]; found {
+			return nil, errors.New("Error: there are several systems named " + system.Name + " in papertrail ")
+		}
+		systems[system.Name] = system
+	}
+	for index := range manifest.Systems {
+		declared := &manifest.Systems[index]
+		desired, err := normalizedManifestSystem(*declared, state.destinations)
+		if err != nil {
+			return nil, err
+		}
+		change := PlanChange{ItemType: "System", Name: declared.Name, system: declared}
+		if system, found := systems[declared.Name]; !found {
+			change.Action = PlanActionCreate
+			change.Fields = diffFields(systemFields(ManifestSystem{}), systemFields(desired))
+		} else {
+			change.Action = PlanActionUpdate
+			change.ID = system.ID
+			change.Fields = diffFields(systemFields(manifestSystemFromSystem(system)), systemFields(desired))
+			for _, field := range change.Fields {
+				if field.Field == "ip_address" {
+					change.Unsupported = "the ip address of a system can't be changed, it has to be deleted and created again"
+				}
+			}
+		}
+		plan.add(change)
+	}
+	groups := map[string]GroupObject{}
+	for _, group := range state.groups {
+		if _, found := groups[group.Name]; found {
+			return nil, errors.New("Error: there are several groups named " + group.Name + " in papertrail ")
+		}
+		groups[group.Name] = group
+		plan.groupIds[group.Name] = group.ID
+	}
+	declaredGroups := map[string]bool{}
+	for index := range manifest.Groups {
+		declared := &manifest.Groups[index]
+		declaredGroups[declared.Name] = true
+		change := PlanChange{ItemType: "Group", Name: declared.Name, group: declared}
+		desired := groupFields(*declared)
+		if group, found := groups[declared.Name]; !found {
+			change.Action = PlanActionCreate
+			change.Fields = diffFields(groupFields(ManifestGroup{}), desired)
+		} else {
+			change.Action = PlanActionUpdate
+			change.ID = int64(group.ID)
+			change.Fields = diffFields(groupFields(ManifestGroup{Name: group.Name, SystemWildcard: group.SystemWildcard}), desired)
+		}
+		plan.add(change)
+	}
+	searches := map[string]SearchObject{}
+	for _, search := range state.searches {
+		key := searchKey(search.Group.Name, search.Name)
+		if _, found := searches[key]; found {
+			return nil, errors.New("Error: there are several searches named " + search.Name + " in the group " +
+				search.Group.Name + " in papertrail ")
+		}
+		searches[key] = search
+	}
+	for index := range manifest.Searches {
+		declared := &manifest.Searches[index]
+		if _, found := groups[declared.Group]; !declaredGroups[declared.Group] && (!found || prune) {
+			return nil, errors.New("Error: the group " + declared.Group + " of the search " + declared.Name +
+				" is not declared in the manifest ")
+		}
+		change := PlanChange{ItemType: "Search", Name: declared.Name, Group: declared.Group, search: declared}
+		desired := searchFields(*declared)
+		if search, found := searches[declared.key()]; !found {
+			change.Action = PlanActionCreate
+			change.Fields = diffFields(searchFields(ManifestSearch{}), desired)
+		} else {
+			change.Action = PlanActionUpdate
+			change.ID = int64(search.ID)
+			change.Fields = diffFields(searchFields(ManifestSearch{Name: search.Name, Group: search.Group.Name,
+				Query: search.Query}), desired)
+		}
+		plan.add(change)
+	}
+	if prune {
+		plan.addDeletes(state, manifest)
+	}
+	return plan, nil
+}
+
+// addDeletes adds to the plan the deletion of the searches, groups and systems of the account not declared
+// in the manifest, in that order so the searches are deleted before their groups
+func (p *Plan) addDeletes(state *accountState, manifest *Manifest) {
+	declaredSearches := map[string]bool{}
+	for _, search := range manifest.Searches {
+		declaredSearches[search.key()] = true
+	}
+	declaredGroups := map[string]bool{}
+	for _, group := range manifest.Groups {
+		declaredGroups[group.Name] = true
+	}
+	var deletes []PlanChange
+	for _, search := range state.searches {
+		if !declaredSearches[searchKey(search.Group.Name, search.Name)] {
+			deletes = append(deletes, PlanChange{Action: PlanActionDelete, ItemType: "Search", Name: search.Name,
+				Group: search.Group.Name, ID: int64(search.ID)})
+		}
+	}
+	for _, group := range state.groups {
+		if !declaredGroups[group.Name] {
+			deletes = append(deletes, PlanChange{Action: PlanActionDelete, ItemType: "Group", Name: group.Name, ID: int64(group.ID)})
+		}
+	}
+	declaredSystems := map[string]bool{}
+	for _, system := range manifest.Systems {
+		declaredSystems[system.Name] = true
+	}
+	for _, system := range state.systems {
+		if !declaredSystems[system.Name] {
+			deletes = append(deletes, PlanChange{Action: PlanActionDelete, ItemType: "System", Name: system.Name, ID: system.ID})
+		}
+	}
+	order := map[string]int{"Search": 0, "Group": 1, "System": 2}
+	sort.SliceStable(deletes, func(i, j int) bool {
+		if deletes[i].ItemType != deletes[j].ItemType {
+			return order[deletes[i].ItemType] < order[deletes[j].ItemType]
+		}
+		return searchKey(deletes[i].Group, deletes[i].Name) < searchKey(deletes[j].Group, deletes[j].Name)
+	})
+	p.Changes = append(p.Changes, deletes...)
+}
+
+// add adds a change to the plan, unless it is an update that doesn't change any field
+func (p *Plan) add(change PlanChange) {
+	if change.Action == PlanActionUpdate && len(change.Fields) == 0 {
+		return
+	}
+	p.Changes = append(p.Changes, change)
+}
+
+// Count returns the number of changes of the plan with the action provided
+func (p *Plan) Count(action string) int {
+	count := 0
+	for _, change := range p.Changes {
+		if change.Action == action {
+			count++
+		}
+	}
+	return count
+}
+
+// ApplyPlan applies the changes of the plan provided in order, returning the ones applied. The changes that
+// can't be applied are skipped with a warning. When a change fails the rest are not applied, and the changes
+// applied until then are returned along with the error
+func (c *Client) ApplyPlan(ctx context.Context, plan *Plan) ([]PlanChange, error) {
+	var applied []PlanChange
+	for _, change := range plan.Changes {
+		if len(change.Unsupported) > 0 {
+			c.logf("Warning: skipping the %s of the %s %s, %s\n", change.Action, change.ItemType, change.Name, change.Unsupported)
+			continue
+		}
+		if err := c.applyChange(ctx, plan, &change); err != nil {
+			return applied, err
+		}
+		applied = append(applied, change)
+	}
+	return applied, nil
+}
+
+// applyChange applies a change of the plan, setting the identifier of the elements created
+func (c *Client) applyChange(ctx context.Context, plan *Plan, change *PlanChange) error {
+	switch {
+	case change.ItemType == "System" && change.Action == PlanActionCreate:
+		var system *System
+		var err error
+		if len(change.system.Hostname) > 0 {
+			system, err = c.CreateSystemBasedInHostname(ctx, change.system.Name, change.system.Hostname,
+				change.system.DestinationPort, change.system.DestinationID)
+		} else {
+			system, err = c.CreateSystemBasedInIPAddress(ctx, change.system.Name, change.system.IPAddress)
+		}
+		if err != nil {
+			return err
+		}
+		change.ID = system.ID
+	case change.ItemType == "System" && change.Action == PlanActionUpdate:
+		_, err := c.UpdateSystem(ctx, change.ID, SystemUpdate{Hostname: change.system.Hostname,
+			DestinationPort: change.system.DestinationPort, DestinationID: change.system.DestinationID})
+		return err
+	case change.ItemType == "Group" && change.Action == PlanActionCreate:
+		group, err := c.CreateGroup(ctx, change.group.Name, change.group.SystemWildcard)
+		if err != nil {
+			return err
+		}
+		change.ID = int64(group.ID)
+		plan.groupIds[group.Name] = group.ID
+	case change.ItemType == "Group" && change.Action == PlanActionUpdate:
+		_, err := c.UpdateGroup(ctx, int(change.ID), change.group.Name, change.group.SystemWildcard)
+		return err
+	case change.ItemType == "Search" && change.Action == PlanActionCreate:
+		search, err := c.CreateSearch(ctx, change.search.Name, change.search.Query, plan.groupIds[change.search.Group])
+		if err != nil {
+			return err
+		}
+		change.ID = int64(search.ID)
+	case change.ItemType == "Search" && change.Action == PlanActionUpdate:
+		_, err := c.UpdateSearch(ctx, int(change.ID), change.search.Name, change.search.Query, plan.groupIds[change.search.Group])
+		return err
+	case change.ItemType == "Search" && change.Action == PlanActionDelete:
+		return c.DeleteSearch(ctx, int(change.ID))
+	case change.ItemType == "Group" && change.Action == PlanActionDelete:
+		return c.DeleteGroup(ctx, int(change.ID))
+	case change.ItemType == "System" && change.Action == PlanActionDelete:
+		return c.DeleteSystem(ctx, change.ID)
+	default:
+		return errors.New("Error: not valid change " + change.Action + " of " + change.ItemType + " ")
+	}
+	return nil
+}
+
+// manifestSystemFromSystem returns the declaration in a manifest of a system of papertrail. The systems with an
+// IP address are considered based in IP address, and the rest based in hostname sending their logs to their port
+func manifestSystemFromSystem(system System) ManifestSystem {
+	if ipAddress, ok := system.IPAddress.(string); ok && len(ipAddress) > 0 {
+		return ManifestSystem{Name: system.Name, IPAddress: ipAddress}
+	}
+	return ManifestSystem{Name: system.Name, Hostname: system.Hostname, DestinationPort: system.Syslog.Port}
+}
+
+// normalizedManifestSystem returns the system declared in a manifest with the destination
+// given by its port, obtaining it from the destinations provided if it is given by its identifier
+func normalizedManifestSystem(system ManifestSystem, destinations []Destination) (ManifestSystem, error) {
+	if system.DestinationID == 0 {
+		return system, nil
+	}
+	for _, destination := range destinations {
+		if destination.ID == system.DestinationID {
+			system.DestinationPort = destination.Syslog.Port
+			system.DestinationID = 0
+			return system, nil
+		}
+	}
+	return system, errors.New("Error: the destination with id " + strconv.Itoa(system.DestinationID) +
+		" of the system " + system.Name + " doesn't exist ")
+}
+
+// systemFields returns the fields of a system declared in a manifest that are compared, as pairs of name and value
+func systemFields(system ManifestSystem) [][2]string {
+	port := ""
+	if system.DestinationPort != 0 {
+		port = strconv.Itoa(system.DestinationPort)
+	}
+	return [][2]string{{"hostname", system.Hostname}, {"ip_address", system.IPAddress}, {"destination_port", port}}
+}
+
+// groupFields returns the fields of a group declared in a manifest that are compared, as pairs of name and value
+func groupFields(group ManifestGroup) [][2]string {
+	return [][2]string{{"system_wildcard", group.SystemWildcard}}
+}
+
+// searchFields returns the fields of a search declared in a manifest that are compared, as pairs of name and value
+func searchFields(search ManifestSearch) [][2]string {
+	return [][2]string{{"query", search.Query}}
+}
+
+// diffFields returns the changes of the fields whose values differ, both lists must contain the same fields in the same order
+func diffFields(before [][2]string, after [][2]string) []FieldChange {
+	var changes []FieldChange
+	for index, field := range after {
+		if before[index][1] != field[1] {
+			changes = append(changes, FieldChange{Field: field[0], Before: before[index][1], After: field[1]})
+		}
+	}
+	return changes
+}
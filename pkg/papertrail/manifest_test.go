@@ -0,0 +1,241 @@
+package papertrail
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	pathFileName := filepath.Join(t.TempDir(), "papertrail.yaml")
+	if err := ioutil.WriteFile(pathFileName, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return pathFileName
+}
+
+func TestReadManifest(t *testing.T) {
+	manifest, err := ReadManifest(writeManifest(t, `
+systems:
+  - name: web-1
+    hostname: web-1.example.com
+    destination_port: 1111
+  - name: db
+    ip_address: 10.0.0.2
+groups:
+  - name: prod
+    system_wildcard: "*prod*"
+searches:
+  - name: errors
+    group: prod
+    query: error
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Systems) != 2 || manifest.Systems[0].DestinationPort != 1111 || manifest.Systems[1].IPAddress != "10.0.0.2" ||
+		len(manifest.Groups) != 1 || manifest.Groups[0].SystemWildcard != "*prod*" ||
+		len(manifest.Searches) != 1 || manifest.Searches[0].Group != "prod" {
+		t.Fatalf("unexpected manifest read: %+v", manifest)
+	}
+}
+
+func TestReadManifest_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		errorText string
+	}{
+		{name: "unknown field", content: "groups:\n  - name: prod\n    wildcard: '*'\n", errorText: "is not valid"},
+		{name: "duplicated system", content: "systems:\n  - {name: a, ip_address: 10.0.0.1}\n  - {name: a, ip_address: 10.0.0.2}\n",
+			errorText: "the system a is declared several times"},
+		{name: "hostname and ip", content: "systems:\n  - {name: a, hostname: a, ip_address: 10.0.0.1}\n",
+			errorText: "either hostname or ip address"},
+		{name: "no destination", content: "systems:\n  - {name: a, hostname: a}\n", errorText: "either destination id or destination port"},
+		{name: "ip with destination", content: "systems:\n  - {name: a, ip_address: 10.0.0.1, destination_port: 1111}\n",
+			errorText: "can't have a destination"},
+		{name: "search without group", content: "searches:\n  - {name: errors, query: error}\n", errorText: "the name and the group"},
+		{name: "duplicated search", content: "searches:\n  - {name: e, group: g, query: a}\n  - {name: e, group: g, query: b}\n",
+			errorText: "the search e of the group g is declared several times"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadManifest(writeManifest(t, tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.errorText) {
+				t.Fatalf("expected error containing %q, obtained %v", tt.errorText, err)
+			}
+		})
+	}
+}
+
+// newFakeAccount starts a fake papertrail with some systems, groups and searches already created
+func newFakeAccount(t *testing.T) (*fakePapertrail, *Client) {
+	fake, server := newFakePapertrail(t, "token")
+	fake.nextId = 100
+	fake.destinations = []Destination{{ID: 5, Syslog: Syslog{Hostname: "logs.papertrailapp.com", Port: 1111}}}
+	fake.systems = []System{
+		{ID: 1, Name: "web-1", Hostname: "web-1.example.com", Syslog: Syslog{Port: 1111}},
+		{ID: 2, Name: "old-system", Hostname: "old.example.com", Syslog: Syslog{Port: 1111}},
+	}
+	fake.groups = []GroupObject{{ID: 10, Name: "prod", SystemWildcard: "*"}}
+	fake.searches = []SearchObject{
+		{ID: 20, Name: "errors", Query: "error", Group: SearchGroup{ID: 10, Name: "prod"}},
+		{ID: 21, Name: "legacy", Query: "legacy", Group: SearchGroup{ID: 10, Name: "prod"}},
+	}
+	return fake, NewClient(server.URL+"/api/v1/", "token", nil, "", log.New(ioutil.Discard, "", 0))
+}
+
+func TestClient_PlanManifestAndApplyPlan(t *testing.T) {
+	fake, client := newFakeAccount(t)
+	manifest := &Manifest{
+		Systems: []ManifestSystem{
+			{Name: "web-1", Hostname: "web-1.example.com", DestinationID: 5},
+			{Name: "db", IPAddress: "10.0.0.2"},
+		},
+		Groups: []ManifestGroup{{Name: "prod", SystemWildcard: "*prod*"}, {Name: "staging", SystemWildcard: "*staging*"}},
+		Searches: []ManifestSearch{
+			{Name: "errors", Group: "prod", Query: "error OR fatal"},
+			{Name: "slow", Group: "staging", Query: "slow"},
+		},
+	}
+	plan, err := client.PlanManifest(context.Background(), manifest, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes []string
+	for _, change := range plan.Changes {
+		changes = append(changes, change.Action+" "+change.ItemType+" "+searchKey(change.Group, change.Name))
+	}
+	expected := []string{"create System /db", "update Group /prod", "create Group /staging", "update Search prod/errors",
+		"create Search staging/slow", "delete Search prod/legacy", "delete System /old-system"}
+	if strings.Join(changes, ", ") != strings.Join(expected, ", ") {
+		t.Fatalf("unexpected changes planned %v, expected %v", changes, expected)
+	}
+	if fields := plan.Changes[1].Fields; len(fields) != 1 || fields[0] != (FieldChange{Field: "system_wildcard", Before: "*", After: "*prod*"}) {
+		t.Fatalf("unexpected fields changed: %+v", fields)
+	}
+	if plan.Count(PlanActionCreate) != 3 || plan.Count(PlanActionUpdate) != 2 || plan.Count(PlanActionDelete) != 2 {
+		t.Fatalf("unexpected count of changes: %+v", plan.Changes)
+	}
+	applied, err := client.ApplyPlan(context.Background(), plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(plan.Changes) || applied[0].ID == 0 {
+		t.Fatalf("unexpected changes applied: %+v", applied)
+	}
+	if len(fake.systems) != 2 || len(fake.groups) != 2 || len(fake.searches) != 2 || fake.searches[1].Group.Name != "staging" {
+		t.Fatalf("unexpected account after applying the plan: %+v %+v %+v", fake.systems, fake.groups, fake.searches)
+	}
+	plan, err = client.PlanManifest(context.Background(), manifest, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Fatalf("expected no changes after applying the plan, obtained %+v", plan.Changes)
+	}
+}
+
+func TestClient_PlanManifestWithoutPrune(t *testing.T) {
+	fake, client := newFakeAccount(t)
+	manifest := &Manifest{
+		Systems:  []ManifestSystem{{Name: "web-1", Hostname: "web-2.example.com", DestinationPort: 1111}},
+		Searches: []ManifestSearch{{Name: "errors", Group: "prod", Query: "error"}},
+	}
+	plan, err := client.PlanManifest(context.Background(), manifest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 1 || plan.Changes[0].Action != PlanActionUpdate || len(plan.Changes[0].Unsupported) > 0 {
+		t.Fatalf("expected only an update of the system, obtained %+v", plan.Changes)
+	}
+	applied, err := client.ApplyPlan(context.Background(), plan)
+	if err != nil || len(applied) != 1 || fake.systems[0].Hostname != "web-2.example.com" || fake.systems[0].ID != 1 {
+		t.Fatalf("expected the system to be updated, obtained %+v %v %+v", applied, err, fake.systems)
+	}
+	ipManifest := &Manifest{Systems: []ManifestSystem{{Name: "old-system", IPAddress: "10.0.0.3"}}}
+	plan, err = client.PlanManifest(context.Background(), ipManifest, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 1 || len(plan.Changes[0].Unsupported) == 0 {
+		t.Fatalf("expected an unsupported update of the ip address of the system, obtained %+v", plan.Changes)
+	}
+	applied, err = client.ApplyPlan(context.Background(), plan)
+	if err != nil || len(applied) != 0 || fake.systems[1].IPAddress != nil {
+		t.Fatalf("expected the update of the system to be skipped, obtained %+v %v", applied, err)
+	}
+	if _, err := client.PlanManifest(context.Background(), manifest, true); err == nil ||
+		!strings.Contains(err.Error(), "the group prod of the search errors is not declared") {
+		t.Fatalf("expected error about the group of the search when pruning, obtained %v", err)
+	}
+	manifest.Systems[0].DestinationPort, manifest.Systems[0].DestinationID = 0, 6
+	if _, err := client.PlanManifest(context.Background(), manifest, false); err == nil ||
+		!strings.Contains(err.Error(), "the destination with id 6 of the system web-1 doesn't exist") {
+		t.Fatalf("expected error about the destination of the system, obtained %v", err)
+	}
+}
+
+func TestClient_ExportManifest(t *testing.T) {
+	fake, client := newFakeAccount(t)
+	fake.systems = append(fake.systems, System{ID: 3, Name: "db", Hostname: "db.example.com", IPAddress: "10.0.0.2"})
+	fake.groups = append(fake.groups, GroupObject{ID: 11, Name: "dev", SystemWildcard: "*dev*"})
+	fake.searches = append(fake.searches, SearchObject{ID: 22, Name: "slow", Query: "slow", Group: SearchGroup{ID: 11, Name: "dev"}})
+	manifest, err := client.ExportManifest(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &Manifest{
+		Systems: []ManifestSystem{
+			{Name: "db", IPAddress: "10.0.0.2"},
+			{Name: "old-system", Hostname: "old.example.com", DestinationPort: 1111},
+			{Name: "web-1", Hostname: "web-1.example.com", DestinationPort: 1111},
+		},
+		Groups: []ManifestGroup{{Name: "dev", SystemWildcard: "*dev*"}, {Name: "prod", SystemWildcard: "*"}},
+		Searches: []ManifestSearch{
+			{Name: "slow", Group: "dev", Query: "slow"},
+			{Name: "errors", Group: "prod", Query: "error"},
+			{Name: "legacy", Group: "prod", Query: "legacy"},
+		},
+	}
+	if !reflect.DeepEqual(manifest, expected) {
+		t.Fatalf("unexpected manifest exported:\n%+v\nexpected:\n%+v", manifest, expected)
+	}
+	plan, err := client.PlanManifest(context.Background(), manifest, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 0 {
+		t.Fatalf("expected no changes applying the manifest exported, obtained %+v", plan.Changes)
+	}
+}
+
+func TestClient_DetectDrift(t *testing.T) {
+	_, client := newFakeAccount(t)
+	manifest := &Manifest{
+		Systems: []ManifestSystem{
+			{Name: "web-1", Hostname: "web-1.example.com", DestinationPort: 2222},
+			{Name: "old-system", Hostname: "old.example.com", DestinationID: 5},
+		},
+		Groups:   []ManifestGroup{{Name: "staging", SystemWildcard: "*staging*"}},
+		Searches: []ManifestSearch{{Name: "errors", Group: "prod", Query: "error"}},
+	}
+	differences, err := client.DetectDrift(context.Background(), manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DriftDifference{
+		{Kind: DriftChanged, ItemType: "System", Name: "web-1", ID: 1,
+			Fields: []FieldChange{{Field: "destination_port", Before: "1111", After: "2222"}}},
+		{Kind: DriftMissing, ItemType: "Group", Name: "staging"},
+		{Kind: DriftUnmanaged, ItemType: "Search", Name: "legacy", Group: "prod", ID: 21},
+		{Kind: DriftUnmanaged, ItemType: "Group", Name: "prod", ID: 10},
+	}
+	if !reflect.DeepEqual(differences, expected) {
+		t.Fatalf("unexpected differences:\n%+v\nexpected:\n%+v", differences, expected)
+	}
+}
@@ -1,30 +1,52 @@
 package papertrail
 
 import (
-	"encoding/json"
-	"log"
-	"strconv"
-	"strings"
+	"context"
 )
 
 // papertrailApiDestinationsEndpoint represents the endpoint for interact with
 // groups in papertrail API
-const papertrailApiDestinationsEndpoint = papertrailApiBaseUrl + "destinations.json"
+const papertrailApiDestinationsEndpoint = "destinations.json"
 
 // checkIfDestinationExistById checks if a system exists on papertrail with the provided identifier
-func checkIfDestinationExistById(destinationId int) (*Destination, error) {
-	destinationIdUrl := strings.SplitAfter(papertrailApiDestinationsEndpoint, "destinations")[0] +
-		"/" + strconv.Itoa(destinationId) + strings.SplitAfter(papertrailApiDestinationsEndpoint, "destinations")[1]
-	getDestination, err := apiOperation("GET", destinationIdUrl, nil)
+func (c *Client) checkIfDestinationExistById(ctx context.Context, destinationId int) (*Destination, error) {
+	destination, err := c.GetDestination(ctx, destinationId)
 	if err != nil {
 		return nil, err
 	}
-	if getDestination.StatusCode == 200 {
-		var destination *Destination
-		json.Unmarshal([]byte(getDestination.Body), &destination)
-		log.Printf("Destination with id %d exists\n", destination.ID)
-		return destination, nil
+	c.logf("Destination with id %d exists\n", destination.ID)
+	return destination, nil
+}
+
+// ListDestinations returns all the log destinations defined in papertrail
+func (c *Client) ListDestinations(ctx context.Context) ([]Destination, error) {
+	getAllDestinations, err := c.apiOperation(ctx, "GET", papertrailApiDestinationsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if getAllDestinations.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getAllDestinations, "Destination", "Obtaining")
+	}
+	var destinations []Destination
+	if err := c.decodeResponse(getAllDestinations, &destinations); err != nil {
+		return nil, err
+	}
+	return destinations, nil
+}
+
+// GetDestination returns the papertrail log destination with the identifier provided
+func (c *Client) GetDestination(ctx context.Context, destinationId int) (*Destination, error) {
+	destinationIdUrl := endpointWithId(papertrailApiDestinationsEndpoint, int64(destinationId))
+	getDestination, err := c.apiOperation(ctx, "GET", destinationIdUrl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if getDestination.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getDestination, "Destination", "Obtaining")
+	}
+	var destination Destination
+	if err := c.decodeResponse(getDestination, &destination); err != nil {
+		return nil, err
 	}
-	err = convertStatusCodeToError(getDestination.StatusCode, "Destination", "Obtaining")
-	return nil, err
+	return &destination, nil
 }
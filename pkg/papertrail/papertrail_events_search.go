@@ -2,123 +2,342 @@ package papertrail
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // papertrailApiDestinationsEndpoint represents the endpoint for interact with
 // groups in papertrail API
-const papertrailApiEventsSearchEndpoint = papertrailApiBaseUrl + "events/search.json"
+const papertrailApiEventsSearchEndpoint = "events/search.json"
 
-// doPapertrailGroupNecessaryActions is in charge of get the logs
-// on the indicated papertrail search and save it in a file
-func doPapertrailEventsSearch(groupName string, groupId int, searchName string, searchQuery string,
-	startDateUnix int64, endDateUnix int64, path string) (*Item, error) {
-	var getEventsSearchResp *ApiResponse
-	var eventsSearchItem *Item
-	numOfEvents := 0
-	pathFileName := CreateFilenameForEventsSearch(path, groupName, searchName, startDateUnix, endDateUnix)
-	papertrailEventsSearch := NewEventsSearchRequestWithMinAndMaxTime(groupId, searchQuery, strconv.Itoa(int(startDateUnix)), strconv.Itoa(int(endDateUnix)))
-	b, err := json.Marshal(papertrailEventsSearch)
+// doPapertrailEventsSearch is in charge of getting the logs of the indicated papertrail search
+// and saving them in files as indicated by the export, resuming the search if it was interrupted
+func (c *Client) doPapertrailEventsSearch(ctx context.Context, groupName string, groupId int, systemIds []int64, searchName string,
+	searchQuery string, startDateUnix int64, endDateUnix int64, export EventsExport) (*Item, error) {
+	if err := export.check(); err != nil {
+		return nil, err
+	}
+	pathFileName, err := CreateFilenameFromTemplate(export.Path, export.FilenameTemplate, export.FilenameTimeFormat,
+		groupName, searchName, searchQuery, startDateUnix, endDateUnix)
 	if err != nil {
 		return nil, err
 	}
-	getEventsSearchResp, err = apiOperation("GET", papertrailApiEventsSearchEndpoint, bytes.NewBuffer(b))
+	checkpointPath := checkpointPathFileName(pathFileName)
+	checkpoint, spools, err := export.prepareSpools(pathFileName, groupId, systemIds, searchQuery, startDateUnix, endDateUnix)
 	if err != nil {
 		return nil, err
 	}
-	if getEventsSearchResp != nil && getEventsSearchResp.StatusCode == 200 {
-		var eventsMessages []string
-		file, err := os.OpenFile(pathFileName, os.O_CREATE|os.O_WRONLY, 0644)
-		defer file.Close()
-		if err != nil {
-			return nil, err
-		}
-		var eventsSearch EventsSearch
-		json.Unmarshal([]byte(getEventsSearchResp.Body), &eventsSearch)
-		if len(eventsSearch.Events) > 0 {
-			for _, event := range eventsSearch.Events {
-				eventsMessages = append(eventsMessages, event.Message)
+	if export.Resume {
+		c.logf("Resuming the search saved in %s, %d events were already obtained\n", pathFileName, checkpoint.eventCount())
+		// The files written by the interrupted search are replaced, in append mode keeping the content they had before it
+		if export.mode() == FileModeAppend {
+			if err := export.restoreFiles(pathFileName, checkpoint.OriginalSizes); err != nil {
+				return nil, err
 			}
-			if eventsSearch.MinTimeAt.Unix() > startDateUnix {
-				maxId := eventsSearch.MinID
-				eventsMessages, err = getPapertrailEventsSearchIterations(groupId, searchQuery, maxId, startDateUnix, eventsMessages)
-				if err != nil {
-					return nil, err
-				}
-			}
-			saveLogsToFile(*file, eventsMessages)
+		} else if export.mode() == FileModeFail {
+			export.Mode = FileModeOverwrite
 		}
-		if eventsMessages != nil {
-			numOfEvents = len(eventsMessages)
+	}
+	errPages := c.getShardsEventsSearchPages(ctx, groupId, searchQuery, checkpoint, spools, export.concurrency(len(checkpoint.Shards)), checkpointPath)
+	if errPages != nil {
+		if checkpoint.eventCount() == 0 {
+			os.RemoveAll(spoolDir(pathFileName))
+			os.Remove(checkpointPath)
+			return nil, errPages
 		}
-		eventsSearchItem = NewItem(0, "EventsSearch", getNameOfFileLogsSaved(pathFileName)+
-			" with "+strconv.Itoa(numOfEvents)+" events retrieved", false, false)
-	} else {
-		err := convertStatusCodeToError(getEventsSearchResp.StatusCode, "EventsSearch", "Obtaining")
+		c.logf("The search saved in %s has been interrupted after obtaining %d events, it can be resumed with the resume option "+
+			"and the dates %s and %s\n", pathFileName, checkpoint.eventCount(), time.Unix(startDateUnix, 0).UTC().Format(time.RFC3339),
+			time.Unix(endDateUnix, 0).UTC().Format(time.RFC3339))
+		if ctx.Err() == nil {
+			return nil, errPages
+		}
+	}
+	// If the search has been cancelled, the events already fetched are saved anyway
+	files, err := saveLogsToFiles(pathFileName, spools, export)
+	if err != nil {
 		return nil, err
 	}
-	return eventsSearchItem, nil
+	eventCount := 0
+	for _, file := range files {
+		eventCount += file.EventCount
+	}
+	if export.Filter != nil {
+		c.logf("%d of the %d events obtained match the filters\n", eventCount, checkpoint.eventCount())
+	}
+	if checkpoint.Truncated {
+		c.logf("Warning: papertrail didn't return all the events of the search, the files %s* may be incomplete\n", pathFileName)
+	}
+	var savedPathFileName string
+	if export.rotated() || export.Manifest {
+		savedPathFileName = pathFileName + ".manifest.json"
+		manifest := EventsManifest{
+			Group:       groupName,
+			SystemIDs:   systemIds,
+			Search:      searchName,
+			Query:       searchQuery,
+			MinTime:     time.Unix(startDateUnix, 0).UTC(),
+			MaxTime:     time.Unix(endDateUnix, 0).UTC(),
+			Compression: strings.ToLower(export.Compression),
+			EventCount:  eventCount,
+			Truncated:   checkpoint.Truncated,
+			Files:       relativeEventsFiles(export.Path, files),
+		}
+		if len(manifest.Compression) == 0 {
+			manifest.Compression = CompressionNone
+		}
+		if err := writeEventsManifest(savedPathFileName, manifest, export.Mode); err != nil {
+			return nil, err
+		}
+	} else {
+		savedPathFileName = files[0].File
+	}
+	if errPages == nil {
+		os.RemoveAll(spoolDir(pathFileName))
+		os.Remove(checkpointPath)
+	}
+	eventsSearchItem := NewEventsSearchItem(savedPathFileName, eventCount)
+	eventsSearchItem.Truncated = checkpoint.Truncated
+	return eventsSearchItem, errPages
 }
 
-// getPapertrailEventsSearchIterations takes care of obtaining events in the specified search
-// when more than one iteration is necessary, since it changes the struct used to send as body
-func getPapertrailEventsSearchIterations(groupId int, searchQuery string, maxId string,
-	startDateUnix int64, eventsMessages []string) ([]string, error) {
-	var eventsSearchIt EventsSearch
-	for {
-		papertrailEventsSearchIt := NewEventsSearchRequestWithMinTimeMaxId(groupId, searchQuery, maxId, strconv.Itoa(int(startDateUnix)))
-		b, err := json.Marshal(papertrailEventsSearchIt)
+// prepareSpools returns the checkpoint and the spools of the shards used by the search whose files start with the
+// path provided. When the search is resumed they are the ones stored by the interrupted search, otherwise they are
+// created empty after checking that the files can be written
+func (e EventsExport) prepareSpools(pathFileName string, groupId int, systemIds []int64, searchQuery string, startDateUnix int64,
+	endDateUnix int64) (*eventsCheckpoint, []*eventsSpool, error) {
+	checkpoint, err := readEventsCheckpoint(checkpointPathFileName(pathFileName))
+	if err != nil {
+		return nil, nil, err
+	}
+	dir := spoolDir(pathFileName)
+	var spools []*eventsSpool
+	if e.Resume {
+		if checkpoint == nil {
+			return nil, nil, errors.New("Error: there isn't an interrupted search to resume for the file " + pathFileName + " ")
+		}
+		if !checkpoint.matches(groupId, systemIds, searchQuery, startDateUnix, endDateUnix, e.shards()) {
+			return nil, nil, errors.New("Error: the interrupted search of the file " + pathFileName +
+				" was performed with a different group, systems, query, dates or number of shards ")
+		}
+		for index, shard := range checkpoint.Shards {
+			spools = append(spools, shard.spool(shardSpoolDir(dir, index)))
+		}
+		return checkpoint, spools, nil
+	}
+	if checkpoint != nil && e.mode() != FileModeOverwrite {
+		return nil, nil, errors.New("Error: there is an interrupted search for the file " + pathFileName +
+			", use resume to continue it or overwrite mode to start it again ")
+	}
+	// The files are checked before obtaining the events, although they are checked again when they are written
+	if err := e.checkFilesAvailable(pathFileName); err != nil {
+		return nil, nil, err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, nil, err
+	}
+	checkpoint = newEventsCheckpoint(groupId, systemIds, searchQuery, startDateUnix, endDateUnix, e.shards())
+	if e.mode() == FileModeAppend {
+		if checkpoint.OriginalSizes, err = e.fileSizes(pathFileName); err != nil {
+			return nil, nil, err
+		}
+	}
+	for index := range checkpoint.Shards {
+		spool, err := newEventsSpool(shardSpoolDir(dir, index))
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		getEventsSearchItResp, err := apiOperation("GET", papertrailApiEventsSearchEndpoint, bytes.NewBuffer(b))
+		spools = append(spools, spool)
+	}
+	return checkpoint, spools, nil
+}
+
+// SearchEvents obtains the log events matching the query in the group provided between the start and
+// end dates and saves them in files as indicated by the export provided. A group identifier 0 searches
+// in all the systems. When a manifest is written, the path of the item returned is the one of the manifest
+func (c *Client) SearchEvents(ctx context.Context, groupName string, groupId int, searchName string, searchQuery string,
+	startDateUnix int64, endDateUnix int64, export EventsExport) (*Item, error) {
+	return c.doPapertrailEventsSearch(ctx, groupName, groupId, nil, searchName, searchQuery, startDateUnix, endDateUnix, export)
+}
+
+// SearchSystemsEvents obtains the log events matching the query in the systems with the identifiers provided between
+// the start and end dates and saves them in files as indicated by the export provided, using the name provided in place
+// of the name of the group. As papertrail only accepts a system in each request, the events of each system are obtained
+// separately (as the shards of the search) and merged in chronological order
+func (c *Client) SearchSystemsEvents(ctx context.Context, systemsName string, systemIds []int64, searchName string,
+	searchQuery string, startDateUnix int64, endDateUnix int64, export EventsExport) (*Item, error) {
+	if len(systemIds) == 0 {
+		return nil, errors.New("Error: it's necessary to provide at least a system to search its events ")
+	}
+	return c.doPapertrailEventsSearch(ctx, systemsName, 0, systemIds, searchName, searchQuery, startDateUnix, endDateUnix, export)
+}
+
+// getEventsSearchPages obtains all the events matching the query in the group or the system provided (in all the
+// systems if both are 0) between the start and end dates. Papertrail returns the events from the newest to the
+// oldest in pages, which are requested until the beginning of the window is reached, passing each one to the handler sorted in chronological
+// order and without the events already obtained in previous pages, along with whether papertrail has reported
+// that the result is truncated until then. The events older than the maximum identifier provided, if any, are
+// obtained. It returns whether papertrail reported that the result was truncated, so some events may be missing
+func (c *Client) getEventsSearchPages(ctx context.Context, groupId int, systemId int64, searchQuery string, startDateUnix int64,
+	endDateUnix int64, maxId string, handlePage func(events []Events, truncated bool) error) (bool, error) {
+	truncated := false
+	minTime := strconv.FormatInt(startDateUnix, 10)
+	maxTime := strconv.FormatInt(endDateUnix, 10)
+	for {
+		request := NewEventsSearchRequest(groupId, searchQuery, "", maxId, minTime, maxTime)
+		request.SystemID = systemId
+		eventsSearch, err := c.getEventsSearchPage(ctx, request)
 		if err != nil {
-			return nil, err
+			return truncated, err
+		}
+		if eventsSearch.ReachedTimeLimit {
+			truncated = true
+		}
+		page := eventsOlderThanId(eventsSearch.Events, maxId, startDateUnix, endDateUnix)
+		if len(page) == 0 {
+			// No progress can be made, so the result is truncated if papertrail reported more events
+			if eventsSearch.ReachedRecordLimit && !eventsSearch.ReachedBeginning {
+				truncated = true
+			}
+			return truncated, nil
+		}
+		if err := handlePage(page, truncated); err != nil {
+			return truncated, err
+		}
+		if eventsSearch.ReachedBeginning ||
+			!eventsSearch.MinTimeAt.IsZero() && eventsSearch.MinTimeAt.Unix() < startDateUnix {
+			return truncated, nil
+		}
+		maxId = page[0].ID
+	}
+}
+
+// getEventsSearchPage obtains a page of the events matching the request provided,
+// sorted from the oldest to the newest
+func (c *Client) getEventsSearchPage(ctx context.Context, request *EventsSearchRequest) (*EventsSearch, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	getEventsSearchResp, err := c.apiOperation(ctx, "GET", papertrailApiEventsSearchEndpoint, bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	if getEventsSearchResp.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getEventsSearchResp, "EventsSearch", "Obtaining")
+	}
+	var eventsSearch EventsSearch
+	if err := c.decodeResponse(getEventsSearchResp, &eventsSearch); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(eventsSearch.Events, func(i, j int) bool {
+		return compareEventIds(eventsSearch.Events[i].ID, eventsSearch.Events[j].ID) < 0
+	})
+	return &eventsSearch, nil
+}
+
+// eventsOlderThanId returns the events, sorted in chronological order, whose identifier is lower than
+// the one provided (if any) and which were received between the start and end dates, removing duplicates
+func eventsOlderThanId(events []Events, maxId string, startDateUnix int64, endDateUnix int64) []Events {
+	var olderEvents []Events
+	for _, event := range events {
+		if len(maxId) > 0 && compareEventIds(event.ID, maxId) >= 0 {
+			continue
 		}
-		json.Unmarshal([]byte(getEventsSearchItResp.Body), &eventsSearchIt)
-		for index := len(eventsSearchIt.Events) - 1; index >= 1; index-- {
-			eventsMessages = append([]string{eventsSearchIt.Events[index].Message}, eventsMessages...)
+		if len(olderEvents) > 0 && olderEvents[len(olderEvents)-1].ID == event.ID {
+			continue
 		}
-		if eventsSearchIt.MinTimeAt.Unix() <= startDateUnix {
-			break
+		if !event.ReceivedAt.IsZero() &&
+			(event.ReceivedAt.Unix() < startDateUnix || event.ReceivedAt.Unix() > endDateUnix) {
+			continue
 		}
-		maxId = eventsSearchIt.MinID
+		olderEvents = append(olderEvents, event)
 	}
-	return eventsMessages, nil
+	return olderEvents
 }
 
-// saveLogsToFile takes care of saving all events in the specified
-// string slice in the received file as a parameter
-func saveLogsToFile(file os.File, eventsSearch []string) {
-	for _, event := range eventsSearch {
-		file.WriteString(event + "\n")
+// saveLogsToFiles takes care of saving the events stored in the spools selected by the filter of the export, merged in chronological order,
+// in the files indicated by the export, whose names start with the path provided, returning the files written.
+// Each file is written in a temporary file renamed when it is complete, which is removed if an error happens
+func saveLogsToFiles(pathFileName string, spools []*eventsSpool, export EventsExport) ([]EventsFile, error) {
+	writer := newEventsFileWriter(pathFileName, export)
+	err := mergeEventsSpools(spools, func(event Events) error {
+		if !export.Filter.Match(event) {
+			return nil
+		}
+		return writer.write(event)
+	})
+	if err != nil {
+		writer.abort()
+		return nil, err
 	}
+	return writer.close()
 }
 
-// CreateFilenameForEventsSearch creates the name of the file where to save the log events from the received parameters
+// Template and layout of the dates used by default to create the names of the files where the log events are saved
+const (
+	DefaultFilenameTemplate   = "{group}_{search}_{start}_{end}"
+	DefaultFilenameTimeFormat = "20060102T150405Z"
+)
+
+// filenamePlaceholder matches the placeholders of the templates of the file names
+var filenamePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// CreateFilenameForEventsSearch creates the name of the file where to save the log events from the received
+// parameters, using the default template of the file names
 func CreateFilenameForEventsSearch(path string, groupName string, searchName string, startDateUnix int64, endDateUnix int64) string {
-	groupNameFixedChars := strings.Replace(groupName, " ", "_", -1)
-	searchNameFixedChars := strings.Replace(searchName, " ", "_", -1)
-	startDateUnixFixedChars := strings.Replace(GetTimeInUTCFromUnixTime(startDateUnix), " ", "_", -1)
-	startDateUnixFixedChars = strings.Replace(startDateUnixFixedChars, "/", "-", -1)
-	endDateUnixFixedChars := strings.Replace(GetTimeInUTCFromUnixTime(endDateUnix), " ", "_", -1)
-	endDateUnixFixedChars = strings.Replace(endDateUnixFixedChars, "/", "-", -1)
-	return path + string(filepath.Separator) + groupNameFixedChars + "_" + searchNameFixedChars + "_" + startDateUnixFixedChars + "_" + endDateUnixFixedChars
+	pathFileName, _ := CreateFilenameFromTemplate(path, DefaultFilenameTemplate, DefaultFilenameTimeFormat,
+		groupName, searchName, "", startDateUnix, endDateUnix)
+	return pathFileName
 }
 
-// getNameOfFileLogsSaved performs the escape of special characters that
-// can cause errors in the file name where the log events will be saved
-func getNameOfFileLogsSaved(pathName string) string {
-	filesPathName := pathName
-	if strings.Contains(filesPathName, "]") {
-		filesPathName = strings.ReplaceAll(filesPathName, "]", "\\]")
+// CreateFilenameFromTemplate creates the name of the file where to save the log events replacing the placeholders
+// {group}, {search}, {query_hash}, {start} and {end} of the template provided, using the layout provided for the
+// dates. The characters of the values that can cause problems in the name of a file are replaced
+func CreateFilenameFromTemplate(path string, template string, timeFormat string, groupName string, searchName string,
+	searchQuery string, startDateUnix int64, endDateUnix int64) (string, error) {
+	if len(template) == 0 {
+		template = DefaultFilenameTemplate
 	}
-	if strings.Contains(filesPathName, "[") {
-		filesPathName = strings.ReplaceAll(filesPathName, "[", "\\[")
+	if len(timeFormat) == 0 {
+		timeFormat = DefaultFilenameTimeFormat
 	}
-	return filesPathName
+	queryHash := sha256.Sum256([]byte(searchQuery))
+	values := map[string]string{
+		"{group}":      groupName,
+		"{search}":     searchName,
+		"{query_hash}": hex.EncodeToString(queryHash[:])[:12],
+		"{start}":      time.Unix(startDateUnix, 0).UTC().Format(timeFormat),
+		"{end}":        time.Unix(endDateUnix, 0).UTC().Format(timeFormat),
+	}
+	var errPlaceholder error
+	name := filenamePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		value, ok := values[placeholder]
+		if !ok {
+			errPlaceholder = errors.New("Error: not valid placeholder " + placeholder + " in the file name template, " +
+				"the only valid values are: {group}, {search}, {query_hash}, {start} or {end} ")
+		}
+		return fixFilenameChars(value)
+	})
+	if errPlaceholder != nil {
+		return "", errPlaceholder
+	}
+	if len(name) == 0 || strings.ContainsAny(name, "/\\") || name == "." || name == ".." {
+		return "", errors.New("Error: the file name template must create a file name without path separators ")
+	}
+	return path + string(filepath.Separator) + name, nil
+}
+
+// fixFilenameChars replaces the characters of a value that can cause problems in the name of a file
+func fixFilenameChars(value string) string {
+	return strings.NewReplacer(" ", "_", "/", "-", "\\", "-", ":", "-").Replace(value)
 }
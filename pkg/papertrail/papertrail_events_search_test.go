@@ -0,0 +1,447 @@
+package papertrail
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newFakeEventsServer returns a server answering the events searches with pages of at most pageSize
+// of the events provided, from the newest to the oldest. Like papertrail, the maximum id is inclusive.
+// When a system is requested, only the events whose source is that system are returned. The requests
+// received are appended to the slice provided
+func newFakeEventsServer(t *testing.T, events []Events, pageSize int, requests *[]EventsSearchRequest) *httptest.Server {
+	server := httptest.NewServer(fakeEventsHandler(events, pageSize, requests))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fakeEventsHandler returns the handler of the server returned by newFakeEventsServer
+func fakeEventsHandler(events []Events, pageSize int, requests *[]EventsSearchRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var request EventsSearchRequest
+		json.Unmarshal(body, &request)
+		*requests = append(*requests, request)
+		minTime, _ := strconv.ParseInt(request.MinTime, 10, 64)
+		maxTime, _ := strconv.ParseInt(request.MaxTime, 10, 64)
+		var matching []Events
+		for i := len(events) - 1; i >= 0; i-- {
+			event := events[i]
+			if event.ReceivedAt.Unix() < minTime || event.ReceivedAt.Unix() > maxTime ||
+				len(request.MaxID) > 0 && compareEventIds(event.ID, request.MaxID) > 0 ||
+				request.SystemID != 0 && event.SourceID != request.SystemID {
+				continue
+			}
+			matching = append(matching, event)
+		}
+		response := EventsSearch{Events: matching, ReachedBeginning: true}
+		if len(matching) > pageSize {
+			response = EventsSearch{Events: matching[:pageSize], ReachedRecordLimit: true}
+		}
+		if len(response.Events) > 0 {
+			response.MinID = response.Events[len(response.Events)-1].ID
+			response.MaxID = response.Events[0].ID
+			response.MinTimeAt = response.Events[len(response.Events)-1].ReceivedAt
+		}
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// eventsEveryMinute returns the number of events provided, received one per minute from the date provided
+func eventsEveryMinute(start time.Time, count int) []Events {
+	var events []Events
+	for i := 1; i <= count; i++ {
+		events = append(events, Events{
+			ID:         strconv.Itoa(i * 10),
+			ReceivedAt: start.Add(time.Duration(i) * time.Minute),
+			Message:    "event " + strconv.Itoa(i),
+		})
+	}
+	return events
+}
+
+func TestClient_SearchEvents_Pagination(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	var requests []EventsSearchRequest
+	server := newFakeEventsServer(t, eventsEveryMinute(start, 12), 3, &requests)
+	dir, err := ioutil.TempDir("", "events-search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// The window leaves out the first and the last two events
+	startDateUnix := start.Add(2 * time.Minute).Unix()
+	endDateUnix := start.Add(10 * time.Minute).Unix()
+	item, err := newTestClient(server.URL, "token").SearchEvents(context.Background(), "group", 7, "search", "error",
+		startDateUnix, endDateUnix, *NewEventsExport(dir, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.EventCount != 9 || item.Truncated {
+		t.Errorf("expected 9 events not truncated, obtained %d events truncated %t", item.EventCount, item.Truncated)
+	}
+	content, err := ioutil.ReadFile(item.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ""
+	for i := 2; i <= 10; i++ {
+		expected += "event " + strconv.Itoa(i) + "\n"
+	}
+	if string(content) != expected {
+		t.Errorf("expected file content:\n%q\nobtained:\n%q", expected, string(content))
+	}
+	// Each page has a duplicated event because the maximum id is inclusive
+	expectedMaxIds := []string{"", "80", "60", "40"}
+	if len(requests) != len(expectedMaxIds) {
+		t.Fatalf("expected %d requests, obtained %d", len(expectedMaxIds), len(requests))
+	}
+	for i, request := range requests {
+		if request.MaxID != expectedMaxIds[i] || request.GroupID != 7 || request.Q != "error" ||
+			request.MinTime != strconv.FormatInt(startDateUnix, 10) || request.MaxTime != strconv.FormatInt(endDateUnix, 10) {
+			t.Errorf("unexpected request %d: %+v", i, request)
+		}
+	}
+}
+
+func TestClient_SearchSystemsEvents(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	events := eventsEveryMinute(start, 9)
+	for i := range events {
+		events[i].SourceID = int64(i%3 + 1)
+	}
+	var requests []EventsSearchRequest
+	server := newFakeEventsServer(t, events, 2, &requests)
+	dir, err := ioutil.TempDir("", "events-systems")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	item, err := newTestClient(server.URL, "token").SearchSystemsEvents(context.Background(), "web-1+db", []int64{3, 1}, "search",
+		"*", start.Unix(), start.Add(time.Hour).Unix(), *NewEventsExport(dir, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(item.FilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "event 1\nevent 3\nevent 4\nevent 6\nevent 7\nevent 9\n"; string(content) != expected {
+		t.Errorf("expected file content %q, obtained %q", expected, string(content))
+	}
+	if filepath.Base(item.FilePath) != "web-1+db_search_20200504T100000Z_20200504T110000Z" {
+		t.Errorf("unexpected file %s", item.FilePath)
+	}
+	for _, request := range requests {
+		if request.GroupID != 0 || request.SystemID != 1 && request.SystemID != 3 {
+			t.Errorf("unexpected request %+v", request)
+		}
+	}
+}
+
+func TestClient_GetEventsSearchPages_ReachedBeginning(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	var requests []EventsSearchRequest
+	server := newFakeEventsServer(t, eventsEveryMinute(start, 4), 10, &requests)
+	var pages [][]Events
+	truncated, err := newTestClient(server.URL, "token").getEventsSearchPages(context.Background(), 0, 0, "*",
+		start.Unix(), start.Add(time.Hour).Unix(), "", func(events []Events, truncated bool) error {
+			pages = append(pages, events)
+			return nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if truncated {
+		t.Error("expected the result not to be truncated")
+	}
+	if len(requests) != 1 || len(pages) != 1 || len(pages[0]) != 4 || pages[0][0].ID != "10" || pages[0][3].ID != "40" {
+		t.Errorf("expected a single chronological page after %d requests, obtained %v", len(requests), pages)
+	}
+}
+
+func TestClient_GetEventsSearchPages_Truncated(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+	}{
+		{
+			name:     "record limit without older events",
+			response: `{"events": [{"id": "10", "message": "only"}], "reached_record_limit": true}`,
+		},
+		{
+			name:     "time limit",
+			response: `{"events": [{"id": "10", "message": "only"}], "reached_beginning": true, "reached_time_limit": true}`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newStaticServer(t, http.StatusOK, test.response)
+			count := 0
+			truncated, err := newTestClient(server.URL, "token").getEventsSearchPages(context.Background(), 0, 0, "*",
+				0, time.Now().Unix(), "", func(events []Events, truncated bool) error {
+					count += len(events)
+					return nil
+				})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !truncated || count != 1 {
+				t.Errorf("expected 1 event truncated, obtained %d events truncated %t", count, truncated)
+			}
+		})
+	}
+}
+
+func TestCreateFilenameFromTemplate(t *testing.T) {
+	start := time.Date(2020, 5, 4, 6, 44, 53, 0, time.UTC).Unix()
+	end := time.Date(2020, 5, 4, 14, 44, 53, 0, time.UTC).Unix()
+	tests := []struct {
+		template   string
+		timeFormat string
+		expected   string
+	}{
+		{"", "", "/tmp/group_test_default_search_20200504T064453Z_20200504T144453Z"},
+		{"{search}-{query_hash}", "", "/tmp/default_search-60da21e34bfb"},
+		{"{group}/{start}", "2006-01-02 15:04", ""},
+		{"logs_{start}_{end}.log", "2006-01-02T15:04", "/tmp/logs_2020-05-04T06-44_2020-05-04T14-44.log"},
+	}
+	for _, test := range tests {
+		pathFileName, err := CreateFilenameFromTemplate("/tmp", test.template, test.timeFormat, "group test",
+			"default search", "severity:error", start, end)
+		if len(test.expected) == 0 {
+			if err == nil {
+				t.Errorf("expected an error for the template %s", test.template)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pathFileName != test.expected {
+			t.Errorf("expected file name %s, obtained %s", test.expected, pathFileName)
+		}
+	}
+	if _, err := CreateFilenameFromTemplate("/tmp", "{group}_{unknown}", "", "group", "search", "*", start, end); err == nil {
+		t.Error("expected an error for an unknown placeholder")
+	}
+}
+
+func TestClient_SearchEvents_Modes(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	var requests []EventsSearchRequest
+	server := newFakeEventsServer(t, eventsEveryMinute(start, 2), 10, &requests)
+	client := newTestClient(server.URL, "token")
+	dir, err := ioutil.TempDir("", "events-search-modes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pathFileName := filepath.Join(dir, "events.log")
+	tests := []struct {
+		mode     string
+		existing string
+		expected string
+	}{
+		{FileModeFail, "", "event 1\nevent 2\n"},
+		{FileModeFail, "previous\n", ""},
+		{FileModeOverwrite, "previous event 1\nprevious event 2\nprevious event 3\n", "event 1\nevent 2\n"},
+		{FileModeAppend, "previous\n", "previous\nevent 1\nevent 2\n"},
+	}
+	for _, test := range tests {
+		os.Remove(pathFileName)
+		if len(test.existing) > 0 {
+			if err := ioutil.WriteFile(pathFileName, []byte(test.existing), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		export := EventsExport{Path: dir, FilenameTemplate: "events.log", Mode: test.mode}
+		_, err := client.SearchEvents(context.Background(), "group", 0, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export)
+		if len(test.expected) == 0 {
+			if err == nil {
+				t.Errorf("expected an error in %s mode with an existing file", test.mode)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := ioutil.ReadFile(pathFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != test.expected {
+			t.Errorf("expected file content in %s mode:\n%q\nobtained:\n%q", test.mode, test.expected, string(content))
+		}
+	}
+	// The temporary files are renamed or removed
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected only the file with the events in the directory, obtained %d files", len(files))
+	}
+}
+
+func TestClient_SearchEvents_Resume(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	events := eventsEveryMinute(start, 10)
+	var requests []EventsSearchRequest
+	failing := true
+	handler := fakeEventsHandler(events, 3, &requests)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The third page fails until the search is resumed
+		if failing && len(requests) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "Bad request"}`))
+			return
+		}
+		handler(w, r)
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL, "token")
+	dir, err := ioutil.TempDir("", "events-search-resume")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	export := EventsExport{Path: dir, FilenameTemplate: "events.log"}
+	pathFileName := filepath.Join(dir, "events.log")
+	if _, err := client.SearchEvents(context.Background(), "group", 0, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export); err == nil {
+		t.Fatal("expected an error when the third page fails")
+	}
+	if _, err := os.Stat(pathFileName + ".checkpoint.json"); err != nil {
+		t.Fatalf("expected a checkpoint of the interrupted search: %v", err)
+	}
+	if _, err := client.SearchEvents(context.Background(), "group", 0, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export); err == nil {
+		t.Fatal("expected an error when an interrupted search is started again without resuming it")
+	}
+	failing = false
+	requests = nil
+	export.Resume = true
+	if _, err := client.SearchEvents(context.Background(), "group", 0, "search", "error", start.Unix(), start.Add(time.Hour).Unix(), export); err == nil {
+		t.Fatal("expected an error when resuming a search with a different query")
+	}
+	item, err := client.SearchEvents(context.Background(), "group", 0, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.EventCount != 10 {
+		t.Errorf("expected 10 events, obtained %d", item.EventCount)
+	}
+	if len(requests) == 0 || requests[0].MaxID != "60" {
+		t.Errorf("expected the resumed search to start from the event 60, obtained requests %+v", requests)
+	}
+	content, err := ioutil.ReadFile(pathFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ""
+	for _, event := range events {
+		expected += event.Message + "\n"
+	}
+	if string(content) != expected {
+		t.Errorf("expected file content:\n%q\nobtained:\n%q", expected, string(content))
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected the checkpoint and the spool to be removed, obtained %d files", len(files))
+	}
+}
+
+func TestClient_SearchEvents_ResumeAppend(t *testing.T) {
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	events := eventsEveryMinute(start, 10)
+	existing := "existing line 1\nexisting line 2\n"
+	for _, cancelled := range []bool{true, false} {
+		var requests []EventsSearchRequest
+		ctx, cancel := context.WithCancel(context.Background())
+		failing := true
+		handler := fakeEventsHandler(events, 3, &requests)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// The search is cancelled after obtaining the second page, or the third page fails until the search is resumed
+			if !cancelled && failing && len(requests) == 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			handler(w, r)
+			if cancelled && len(requests) == 2 {
+				cancel()
+			}
+		}))
+		client := newTestClient(server.URL, "token")
+		client.MaxRetries = 0
+		dir := t.TempDir()
+		export := EventsExport{Path: dir, FilenameTemplate: "events.log", Mode: FileModeAppend}
+		pathFileName := filepath.Join(dir, "events.log")
+		if err := ioutil.WriteFile(pathFileName, []byte(existing), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.SearchEvents(ctx, "group", 0, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export); err == nil {
+			t.Fatalf("expected an error when the search is interrupted (cancelled %t)", cancelled)
+		}
+		failing = false
+		export.Resume = true
+		item, err := client.SearchEvents(context.Background(), "group", 0, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export)
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if item.EventCount != 10 {
+			t.Errorf("expected 10 events (cancelled %t), obtained %d", cancelled, item.EventCount)
+		}
+		content, err := ioutil.ReadFile(pathFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := existing
+		for _, event := range events {
+			expected += event.Message + "\n"
+		}
+		if string(content) != expected {
+			t.Errorf("expected the existing content followed by each event once (cancelled %t):\n%q\nobtained:\n%q",
+				cancelled, expected, string(content))
+		}
+	}
+}
+
+func TestClient_SearchEvents_FailedWithoutEvents(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Write([]byte(`{"events": [], "reached_beginning": true}`))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL, "token")
+	client.MaxRetries = 0
+	start := time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	// The second shard fails after the first one finishes without events, whose progress is stored in the checkpoint
+	export := EventsExport{Path: dir, FilenameTemplate: "events.log", Shards: 2, Concurrency: 1}
+	if _, err := client.SearchEvents(context.Background(), "group", 0, "search", "*", start.Unix(), start.Add(time.Hour).Unix(), export); err == nil {
+		t.Fatal("expected an error when the pages fail")
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected the checkpoint and the spool to be removed when no events were obtained, obtained %d files", len(files))
+	}
+}
@@ -0,0 +1,139 @@
+package papertrail
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Default intervals used to poll papertrail for new events in tail mode
+const (
+	defaultTailInterval    = 2 * time.Second
+	defaultTailMaxInterval = 30 * time.Second
+)
+
+// TailEvents follows the events matching the query in the group provided as they arrive, calling the
+// handler for each one in order. The events are polled every interval, doubling it up to the maximum
+// interval while no new events arrive. The polling continues from the event with the identifier
+// provided as minimum id, or from the most recent events if it is empty. It stops when the context is
+// done or an error occurs, returning the identifier of the last event handled, which can be used as
+// minimum id to resume it without duplicates or gaps
+func (c *Client) TailEvents(ctx context.Context, groupId int, searchQuery string, minId string,
+	interval time.Duration, maxInterval time.Duration, handler func(event Events) error) (string, error) {
+	return c.tailEvents(ctx, groupId, nil, searchQuery, minId, interval, maxInterval, handler)
+}
+
+// TailSystemsEvents follows the events matching the query in the systems with the identifiers provided as they
+// arrive, like TailEvents. As papertrail only accepts a system in each request, every poll requests the new
+// events of each system, which are merged in chronological order
+func (c *Client) TailSystemsEvents(ctx context.Context, systemIds []int64, searchQuery string, minId string,
+	interval time.Duration, maxInterval time.Duration, handler func(event Events) error) (string, error) {
+	if len(systemIds) == 0 {
+		return minId, errors.New("Error: it's necessary to provide at least a system to follow its events ")
+	}
+	return c.tailEvents(ctx, 0, systemIds, searchQuery, minId, interval, maxInterval, handler)
+}
+
+// tailEvents follows the events matching the query in the group or in the systems provided as they arrive
+func (c *Client) tailEvents(ctx context.Context, groupId int, systemIds []int64, searchQuery string, minId string,
+	interval time.Duration, maxInterval time.Duration, handler func(event Events) error) (string, error) {
+	if interval <= 0 {
+		interval = defaultTailInterval
+	}
+	if maxInterval <= 0 {
+		maxInterval = defaultTailMaxInterval
+	}
+	if maxInterval < interval {
+		maxInterval = interval
+	}
+	cursor := minId
+	wait := interval
+	for {
+		newEvents, pending, err := c.getNewEvents(ctx, groupId, systemIds, searchQuery, cursor)
+		if err != nil {
+			return cursor, err
+		}
+		for _, event := range newEvents {
+			if err := handler(event); err != nil {
+				return cursor, err
+			}
+			cursor = event.ID
+		}
+		if len(newEvents) > 0 && pending {
+			// There are more events pending, they are requested without waiting
+			continue
+		}
+		if len(newEvents) > 0 {
+			wait = interval
+		} else if wait*2 <= maxInterval {
+			wait *= 2
+		} else {
+			wait = maxInterval
+		}
+		if err := sleepContext(ctx, wait); err != nil {
+			return cursor, err
+		}
+	}
+}
+
+// getNewEvents returns the events matching the query in the group or in each one of the systems provided whose
+// identifier is greater than the cursor, sorted in chronological order, and whether papertrail has more events
+// pending. When papertrail has more events pending for a system, the events of the rest of the systems newer
+// than the last one returned for it are left for the next request, so none of them is skipped
+func (c *Client) getNewEvents(ctx context.Context, groupId int, systemIds []int64, searchQuery string,
+	cursor string) ([]Events, bool, error) {
+	var events []Events
+	limitId := ""
+	for _, systemId := range shardsSystemIds(systemIds) {
+		request := NewEventsSearchRequest(groupId, searchQuery, cursor, "", "", "")
+		request.SystemID = systemId
+		eventsSearch, err := c.getEventsSearchPage(ctx, request)
+		if err != nil {
+			return nil, false, err
+		}
+		var systemEvents []Events
+		for _, event := range eventsSearch.Events {
+			if len(cursor) > 0 && compareEventIds(event.ID, cursor) <= 0 {
+				continue
+			}
+			systemEvents = append(systemEvents, event)
+		}
+		if eventsSearch.ReachedRecordLimit && len(systemEvents) > 0 {
+			lastId := systemEvents[len(systemEvents)-1].ID
+			if len(limitId) == 0 || compareEventIds(lastId, limitId) < 0 {
+				limitId = lastId
+			}
+		}
+		events = append(events, systemEvents...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return compareEventIds(events[i].ID, events[j].ID) < 0
+	})
+	var newEvents []Events
+	for _, event := range events {
+		if len(limitId) > 0 && compareEventIds(event.ID, limitId) > 0 {
+			break
+		}
+		if len(newEvents) > 0 && newEvents[len(newEvents)-1].ID == event.ID {
+			continue
+		}
+		newEvents = append(newEvents, event)
+	}
+	return newEvents, len(limitId) > 0, nil
+}
+
+// compareEventIds compares two event identifiers, which are numbers too large to be represented
+// as integers, returning -1, 0 or 1 if the first one is lower, equal or greater than the second one
+func compareEventIds(a string, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
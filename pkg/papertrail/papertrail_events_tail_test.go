@@ -0,0 +1,118 @@
+package papertrail
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient_TailEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Each response is returned for the request whose minimum id is its key, the duplicated
+	// event 2 must be skipped and the response without events must not move the cursor
+	responses := map[string]string{
+		"":  `{"min_id": "1", "max_id": "2", "events": [{"id": "2", "message": "second"}, {"id": "1", "message": "first"}]}`,
+		"2": `{"min_id": "2", "max_id": "10", "events": [{"id": "2", "message": "second"}, {"id": "10", "message": "third"}]}`,
+	}
+	var minIds []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var request EventsSearchRequest
+		json.Unmarshal(body, &request)
+		minIds = append(minIds, request.MinID)
+		if len(minIds) == 4 {
+			cancel()
+		}
+		if response, ok := responses[request.MinID]; ok {
+			w.Write([]byte(response))
+			return
+		}
+		w.Write([]byte(`{"min_id": "10", "max_id": "10", "events": []}`))
+	}))
+	defer server.Close()
+	var messages []string
+	cursor, err := newTestClient(server.URL, "token").TailEvents(ctx, 7, "error", "", time.Millisecond, 2*time.Millisecond,
+		func(event Events) error {
+			messages = append(messages, event.Message)
+			return nil
+		})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the tail to be cancelled, obtained %v", err)
+	}
+	if cursor != "10" {
+		t.Errorf("expected cursor 10, obtained %s", cursor)
+	}
+	if len(messages) != 3 || messages[0] != "first" || messages[1] != "second" || messages[2] != "third" {
+		t.Errorf("unexpected events received: %v", messages)
+	}
+	expectedMinIds := []string{"", "2", "10", "10"}
+	for i, minId := range expectedMinIds {
+		if i >= len(minIds) || minIds[i] != minId {
+			t.Fatalf("expected requests with minimum ids %v, obtained %v", expectedMinIds, minIds)
+		}
+	}
+}
+
+func TestClient_TailSystemsEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The system 1 has more events pending after the event 20, so the event 30 of the system 2 must
+	// wait until the next request in which the event 25 of the system 1 is obtained
+	responses := map[string]string{
+		"1-":   `{"reached_record_limit": true, "events": [{"id": "20", "message": "1b"}, {"id": "10", "message": "1a"}]}`,
+		"2-":   `{"events": [{"id": "15", "message": "2a"}, {"id": "30", "message": "2b"}]}`,
+		"1-20": `{"events": [{"id": "25", "message": "1c"}]}`,
+		"2-20": `{"events": [{"id": "30", "message": "2b"}]}`,
+	}
+	var requests []EventsSearchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var request EventsSearchRequest
+		json.Unmarshal(body, &request)
+		requests = append(requests, request)
+		if len(requests) == 6 {
+			cancel()
+		}
+		if response, ok := responses[strconv.FormatInt(request.SystemID, 10)+"-"+request.MinID]; ok {
+			w.Write([]byte(response))
+			return
+		}
+		w.Write([]byte(`{"events": []}`))
+	}))
+	defer server.Close()
+	var messages []string
+	cursor, err := newTestClient(server.URL, "token").TailSystemsEvents(ctx, []int64{1, 2}, "error", "", time.Millisecond,
+		2*time.Millisecond, func(event Events) error {
+			messages = append(messages, event.Message)
+			return nil
+		})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the tail to be cancelled, obtained %v", err)
+	}
+	if cursor != "30" {
+		t.Errorf("expected cursor 30, obtained %s", cursor)
+	}
+	if strings.Join(messages, " ") != "1a 2a 1b 1c 2b" {
+		t.Errorf("unexpected events received: %v", messages)
+	}
+	for _, request := range requests {
+		if request.GroupID != 0 || request.SystemID == 0 {
+			t.Errorf("unexpected request %+v", request)
+		}
+	}
+}
+
+func TestCompareEventIds(t *testing.T) {
+	if compareEventIds("1234567890123456789012", "999999999999999999999") != 1 ||
+		compareEventIds("10", "9") != 1 || compareEventIds("9", "10") != -1 || compareEventIds("42", "42") != 0 {
+		t.Error("unexpected comparison of event ids")
+	}
+}
@@ -2,38 +2,41 @@ package papertrail
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"log"
 	"strconv"
-	"strings"
 )
 
 // papertrailApiGroupsEndpoint represents the endpoint for interact with
 // groups in papertrail API
-const papertrailApiGroupsEndpoint = papertrailApiBaseUrl + "groups.json"
+const papertrailApiGroupsEndpoint = "groups.json"
 
 // doPapertrailGroupNecessaryActions is in charge of carrying out the indicated actions
-// on the indicated papertrail group, as well as checking if it exists
-func doPapertrailGroupNecessaryActions(groupName string, actionName string, systemWildcard string, deleteAllSystems bool) (*Item, error) {
+// on the indicated papertrail group, as well as checking if it exists. When the action is create, ensureWildcard
+// is true and the group exists with a different system wildcard it is updated, unless noUpdate is true
+func (c *Client) doPapertrailGroupNecessaryActions(ctx context.Context, groupName string, actionName string, systemWildcard string,
+	deleteAllSystems bool, ensureWildcard bool, noUpdate bool) (*Item, error) {
 	var groupItem *Item
-	groupObject, err := checkGroupExists(groupName)
+	groupObject, err := c.checkGroupExists(ctx, groupName)
 	if err != nil {
 		return nil, err
 	}
 	if groupObject != nil {
-		log.Printf("Group with name %s exists with id %d\n", groupName, groupObject.ID)
-		if ActionIsObtain(actionName) || ActionIsCreate(actionName) {
+		c.logf("Group with name %s exists with id %d\n", groupName, groupObject.ID)
+		if ActionIsCreate(actionName) && ensureWildcard {
+			return c.ensureGroupSystemWildcard(ctx, groupObject, systemWildcard, noUpdate)
+		} else if ActionIsObtain(actionName) || ActionIsCreate(actionName) {
 			return NewItem(groupObject.ID, "Group", groupName, false, false), nil
 		} else if ActionIsDelete(actionName) {
-			groupItem, err = deleteGroup(groupObject.ID, groupObject.Name)
+			groupItem, err = c.deleteGroup(ctx, groupObject.ID, groupObject.Name)
 			if err != nil {
 				return nil, err
 			}
 		}
 	} else {
 		if ActionIsCreate(actionName) {
-			groupItem, err = createGroup(groupName, systemWildcard)
+			groupItem, err = c.createGroup(ctx, groupName, systemWildcard)
 			if err != nil {
 				return nil, err
 			}
@@ -46,17 +49,35 @@ func doPapertrailGroupNecessaryActions(groupName string, actionName string, syst
 }
 
 // createGroup attempts to create a group in papertrail using the parameters provided as group information
-func createGroup(groupName string, systemWildcard string) (*Item, error) {
-	papertrailGroupCreated, err := createPapertrailGroupOperation(groupName, systemWildcard)
+func (c *Client) createGroup(ctx context.Context, groupName string, systemWildcard string) (*Item, error) {
+	papertrailGroupCreated, err := c.createPapertrailGroupOperation(ctx, groupName, systemWildcard)
 	if err != nil {
 		return nil, err
 	}
 	return NewItem(papertrailGroupCreated.ID, "Group", papertrailGroupCreated.Name, true, false), nil
 }
 
+// ensureGroupSystemWildcard updates the system wildcard of an existing group if it differs from the one
+// provided. When noUpdate is true the group is left as it is, only warning about the difference
+func (c *Client) ensureGroupSystemWildcard(ctx context.Context, group *GroupObject, systemWildcard string, noUpdate bool) (*Item, error) {
+	if group.SystemWildcard == systemWildcard {
+		return NewItem(group.ID, "Group", group.Name, false, false), nil
+	}
+	if noUpdate {
+		c.logf("Warning: group with name %s has the system wildcard %s instead of %s, it's not updated\n",
+			group.Name, group.SystemWildcard, systemWildcard)
+		return NewItem(group.ID, "Group", group.Name, false, false), nil
+	}
+	if _, err := c.UpdateGroup(ctx, group.ID, group.Name, systemWildcard); err != nil {
+		return nil, err
+	}
+	return NewUpdatedItem(group.ID, "Group", group.Name,
+		[]FieldChange{{Field: "system_wildcard", Before: group.SystemWildcard, After: systemWildcard}}), nil
+}
+
 // deleteGroup attempts to delete a group using the parameters provided as group information
-func deleteGroup(groupId int, groupName string) (*Item, error) {
-	papertrailGroupDeleted, err := deletePapertrailGroupOperation(groupName, groupId)
+func (c *Client) deleteGroup(ctx context.Context, groupId int, groupName string) (*Item, error) {
+	papertrailGroupDeleted, err := c.deletePapertrailGroupOperation(ctx, groupName, groupId)
 	if err != nil {
 		return nil, err
 	}
@@ -67,28 +88,112 @@ func deleteGroup(groupId int, groupName string) (*Item, error) {
 }
 
 // checkGroupExists checks if a group exists in papertrail, returning the information of this one in case it exists
-func checkGroupExists(groupName string) (*GroupObject, error) {
+func (c *Client) checkGroupExists(ctx context.Context, groupName string) (*GroupObject, error) {
 	var group *GroupObject
-	getAllGroupResp, err := apiOperation("GET", papertrailApiGroupsEndpoint, nil)
+	groups, err := c.ListGroups(ctx)
 	if err != nil {
-		return group, err
-	}
-	if getAllGroupResp.StatusCode == 200 {
-		var groups []GroupObject
-		json.Unmarshal([]byte(getAllGroupResp.Body), &groups)
-		for _, item := range groups {
-			if item.Name == groupName {
-				group = NewGroupObject(item.ID, item.Name, item.SystemWildcard, item.Links, item.Systems)
-				break
-			}
+		return nil, err
+	}
+	for _, item := range groups {
+		if item.Name == groupName {
+			group = NewGroupObject(item.ID, item.Name, item.SystemWildcard, item.Links, item.Systems)
+			break
 		}
 	}
 	return group, nil
 }
 
+// ListGroups returns all the groups defined in papertrail
+func (c *Client) ListGroups(ctx context.Context) ([]GroupObject, error) {
+	getAllGroupResp, err := c.apiOperation(ctx, "GET", papertrailApiGroupsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if getAllGroupResp.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getAllGroupResp, "Group", "Obtaining")
+	}
+	var groups []GroupObject
+	if err := c.decodeResponse(getAllGroupResp, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetGroup returns the papertrail group with the identifier provided
+func (c *Client) GetGroup(ctx context.Context, groupId int) (*GroupObject, error) {
+	getGroupResp, err := c.apiOperation(ctx, "GET", endpointWithId(papertrailApiGroupsEndpoint, int64(groupId)), nil)
+	if err != nil {
+		return nil, err
+	}
+	if getGroupResp.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getGroupResp, "Group", "Obtaining")
+	}
+	var group GroupObject
+	if err := c.decodeResponse(getGroupResp, &group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// FindGroup returns the papertrail group whose identifier or name is the reference provided
+func (c *Client) FindGroup(ctx context.Context, reference string) (*GroupObject, error) {
+	if groupId, err := strconv.Atoi(reference); err == nil {
+		return c.GetGroup(ctx, groupId)
+	}
+	group, err := c.checkGroupExists(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil {
+		return nil, newNotFoundError("Group", reference)
+	}
+	return group, nil
+}
+
+// CreateGroup creates a papertrail group with the name and system wildcard provided
+func (c *Client) CreateGroup(ctx context.Context, groupName string, systemWildcard string) (*GroupObject, error) {
+	return c.createPapertrailGroupOperation(ctx, groupName, systemWildcard)
+}
+
+// UpdateGroup changes the name and system wildcard of the papertrail group with the identifier provided
+func (c *Client) UpdateGroup(ctx context.Context, groupId int, groupName string, systemWildcard string) (*GroupObject, error) {
+	papertrailGroupToUpdate := GroupCreationObject{Group: GroupCreateObject{
+		Name:           groupName,
+		SystemWildcard: systemWildcard,
+	}}
+	b, err := json.Marshal(papertrailGroupToUpdate)
+	if err != nil {
+		return nil, err
+	}
+	updateGroupResp, err := c.apiOperation(ctx, "PUT", endpointWithId(papertrailApiGroupsEndpoint, int64(groupId)), bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	if updateGroupResp.StatusCode != 200 {
+		c.logf("Problems updating group with id %d\n", groupId)
+		return nil, convertStatusCodeToError(updateGroupResp, "Group", "Updating")
+	}
+	var group GroupObject
+	if err := c.decodeResponse(updateGroupResp, &group); err != nil {
+		return nil, err
+	}
+	c.logf("Group with name %s and id %d was successfully updated\n", group.Name, group.ID)
+	return &group, nil
+}
+
+// DeleteGroup deletes the papertrail group with the identifier provided
+func (c *Client) DeleteGroup(ctx context.Context, groupId int) error {
+	group, err := c.GetGroup(ctx, groupId)
+	if err != nil {
+		return err
+	}
+	_, err = c.deletePapertrailGroupOperation(ctx, group.Name, group.ID)
+	return err
+}
+
 // createPapertrailGroupOperation do the necessary calls in papertrail
 // to create a group using the parameter information provided as the group information to be created
-func createPapertrailGroupOperation(groupName string, systemWildcard string) (*GroupObject, error) {
+func (c *Client) createPapertrailGroupOperation(ctx context.Context, groupName string, systemWildcard string) (*GroupObject, error) {
 	papertrailGroupToCreate := GroupCreationObject{Group: GroupCreateObject{
 		Name:           groupName,
 		SystemWildcard: systemWildcard,
@@ -97,37 +202,41 @@ func createPapertrailGroupOperation(groupName string, systemWildcard string) (*G
 	if err != nil {
 		return nil, err
 	}
-	createGroupResp, err := apiOperation("POST", papertrailApiGroupsEndpoint, bytes.NewBuffer(b))
+	createGroupResp, err := c.apiOperation(ctx, "POST", papertrailApiGroupsEndpoint, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
 	if createGroupResp.StatusCode == 200 {
 		var group GroupObject
-		json.Unmarshal([]byte(createGroupResp.Body), &group)
-		log.Printf("Group with name %s and id %d was successfully created\n", group.Name, group.ID)
+		if err := c.decodeResponse(createGroupResp, &group); err != nil {
+			return nil, err
+		}
+		if err := checkCreatedId(createGroupResp, int64(group.ID), "group"); err != nil {
+			return nil, err
+		}
+		c.logf("Group with name %s and id %d was successfully created\n", group.Name, group.ID)
 		return &group, nil
 	}
-	log.Printf("Problems creating group with name %s\n", groupName)
-	err = convertStatusCodeToError(createGroupResp.StatusCode, "Group", "Creating")
+	c.logf("Problems creating group with name %s\n", groupName)
+	err = convertStatusCodeToError(createGroupResp, "Group", "Creating")
 	return nil, err
 }
 
 // deletePapertrailGroupOperation do the necessary calls in papertrail
 // to delete a group using the parameter information provided as the group information to be deleted
-func deletePapertrailGroupOperation(groupName string, groupId int) (*bool, error) {
+func (c *Client) deletePapertrailGroupOperation(ctx context.Context, groupName string, groupId int) (*bool, error) {
 	deleted := false
-	groupIdUrl := strings.SplitAfter(papertrailApiGroupsEndpoint, "groups")[0] +
-		"/" + strconv.Itoa(groupId) + strings.SplitAfter(papertrailApiGroupsEndpoint, "groups")[1]
-	deleteGroupResp, err := apiOperation("DELETE", groupIdUrl, nil)
+	groupIdUrl := endpointWithId(papertrailApiGroupsEndpoint, int64(groupId))
+	deleteGroupResp, err := c.apiOperation(ctx, "DELETE", groupIdUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 	if deleteGroupResp.StatusCode == 200 {
 		deleted = true
-		log.Printf("Group with name %s and id %d was successfully deleted\n", groupName, groupId)
+		c.logf("Group with name %s and id %d was successfully deleted\n", groupName, groupId)
 		return &deleted, nil
 	}
-	log.Printf("Problems deleting group with id %d\n", groupId)
-	err = convertStatusCodeToError(deleteGroupResp.StatusCode, "Group", "Deleting")
+	c.logf("Problems deleting group with id %d\n", groupId)
+	err = convertStatusCodeToError(deleteGroupResp, "Group", "Deleting")
 	return &deleted, err
 }
@@ -2,39 +2,41 @@ package papertrail
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"log"
 	"strconv"
-	"strings"
 )
 
 // papertrailApiSearchesEndpoint represents the endpoint for interact with
 // searches in papertrail API
-const papertrailApiSearchesEndpoint = papertrailApiBaseUrl + "searches.json"
+const papertrailApiSearchesEndpoint = "searches.json"
 
 // doPapertrailSearchesNecessaryActions is in charge of carrying out the indicated actions
-// on the indicated papertrail search, as well as checking if it exists
-func doPapertrailSearchNecessaryActions(searchName string, searchQuery string, groupId int,
-	actionName string) (*Item, error) {
+// on the indicated papertrail search, as well as checking if it exists. When the action is create, ensureQuery
+// is true and the search exists with a different query it is updated, unless noUpdate is true
+func (c *Client) doPapertrailSearchNecessaryActions(ctx context.Context, searchName string, searchQuery string, groupId int,
+	actionName string, ensureQuery bool, noUpdate bool) (*Item, error) {
 	var searchItem *Item
-	searchObject, err := checkSearchExists(searchName, groupId)
+	searchObject, err := c.checkSearchExists(ctx, searchName, groupId)
 	if err != nil {
 		return nil, err
 	}
 	if searchObject != nil {
-		log.Printf("Search with name %s exists with id %d\n", searchName, searchObject.ID)
-		if ActionIsObtain(actionName) || ActionIsCreate(actionName) {
+		c.logf("Search with name %s exists with id %d\n", searchName, searchObject.ID)
+		if ActionIsCreate(actionName) && ensureQuery {
+			return c.ensureSearchQuery(ctx, searchObject, searchQuery, groupId, noUpdate)
+		} else if ActionIsObtain(actionName) || ActionIsCreate(actionName) {
 			return NewItem(searchObject.ID, "Search", searchName, false, false), nil
 		} else if ActionIsDelete(actionName) {
-			searchItem, err = deleteSearch(searchName, searchObject.ID)
+			searchItem, err = c.deleteSearch(ctx, searchName, searchObject.ID)
 			if err != nil {
 				return nil, err
 			}
 		}
 	} else {
 		if ActionIsCreate(actionName) {
-			searchItem, err = createSearch(searchName, searchQuery, groupId)
+			searchItem, err = c.createSearch(ctx, searchName, searchQuery, groupId)
 			if err != nil {
 				return nil, err
 			}
@@ -47,17 +49,35 @@ func doPapertrailSearchNecessaryActions(searchName string, searchQuery string, g
 }
 
 // createSearch attempts to create a search in papertrail using the parameters provided as search information
-func createSearch(searchName string, searchQuery string, groupId int) (*Item, error) {
-	papertrailSearchCreated, err := createPapertrailSearchOperation(searchName, searchQuery, groupId)
+func (c *Client) createSearch(ctx context.Context, searchName string, searchQuery string, groupId int) (*Item, error) {
+	papertrailSearchCreated, err := c.createPapertrailSearchOperation(ctx, searchName, searchQuery, groupId)
 	if err != nil {
 		return nil, err
 	}
 	return NewItem(papertrailSearchCreated.ID, "Search", searchName, true, false), nil
 }
 
+// ensureSearchQuery updates the query of an existing search if it differs from the one provided.
+// When noUpdate is true the search is left as it is, only warning about the difference
+func (c *Client) ensureSearchQuery(ctx context.Context, search *SearchObject, searchQuery string, groupId int, noUpdate bool) (*Item, error) {
+	if search.Query == searchQuery {
+		return NewItem(search.ID, "Search", search.Name, false, false), nil
+	}
+	if noUpdate {
+		c.logf("Warning: search with name %s has the query %s instead of %s, it's not updated\n",
+			search.Name, search.Query, searchQuery)
+		return NewItem(search.ID, "Search", search.Name, false, false), nil
+	}
+	if _, err := c.UpdateSearch(ctx, search.ID, search.Name, searchQuery, groupId); err != nil {
+		return nil, err
+	}
+	return NewUpdatedItem(search.ID, "Search", search.Name,
+		[]FieldChange{{Field: "query", Before: search.Query, After: searchQuery}}), nil
+}
+
 // deleteSearch attempts to delete a search using the parameters provided as search information
-func deleteSearch(searchName string, searchId int) (*Item, error) {
-	papertrailSearchDeleted, err := deletePapertrailSearchOperation(searchName, searchId)
+func (c *Client) deleteSearch(ctx context.Context, searchName string, searchId int) (*Item, error) {
+	papertrailSearchDeleted, err := c.deletePapertrailSearchOperation(ctx, searchName, searchId)
 	if err != nil {
 		return nil, err
 	}
@@ -69,28 +89,124 @@ func deleteSearch(searchName string, searchId int) (*Item, error) {
 
 // checkSearchExists checks if a search exists in papertrail specific group, returning the information
 // of this one in case it exists
-func checkSearchExists(searchName string, groupId int) (*SearchObject, error) {
+func (c *Client) checkSearchExists(ctx context.Context, searchName string, groupId int) (*SearchObject, error) {
 	var search *SearchObject
-	getAllSearchesResp, err := apiOperation("GET", papertrailApiSearchesEndpoint, nil)
+	searches, err := c.ListSearches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range searches {
+		if item.Name == searchName && item.Group.ID == groupId {
+			search = NewSearchObject(item.ID, item.Name, item.Query, item.Group, item.Links)
+			break
+		}
+	}
+	return search, nil
+}
+
+// ListSearches returns all the saved searches defined in papertrail
+func (c *Client) ListSearches(ctx context.Context) ([]SearchObject, error) {
+	getAllSearchesResp, err := c.apiOperation(ctx, "GET", papertrailApiSearchesEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if getAllSearchesResp.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getAllSearchesResp, "Search", "Obtaining")
+	}
+	var searches []SearchObject
+	if err := c.decodeResponse(getAllSearchesResp, &searches); err != nil {
+		return nil, err
+	}
+	return searches, nil
+}
+
+// GetSearch returns the papertrail saved search with the identifier provided
+func (c *Client) GetSearch(ctx context.Context, searchId int) (*SearchObject, error) {
+	getSearchResp, err := c.apiOperation(ctx, "GET", endpointWithId(papertrailApiSearchesEndpoint, int64(searchId)), nil)
+	if err != nil {
+		return nil, err
+	}
+	if getSearchResp.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getSearchResp, "Search", "Obtaining")
+	}
+	var search SearchObject
+	if err := c.decodeResponse(getSearchResp, &search); err != nil {
+		return nil, err
+	}
+	return &search, nil
+}
+
+// FindSearch returns the papertrail saved search whose identifier or name is the reference provided.
+// When looking up by name, only the searches of the group provided are considered unless it is 0
+func (c *Client) FindSearch(ctx context.Context, reference string, groupId int) (*SearchObject, error) {
+	if searchId, err := strconv.Atoi(reference); err == nil {
+		return c.GetSearch(ctx, searchId)
+	}
+	searches, err := c.ListSearches(ctx)
 	if err != nil {
-		return search, err
-	}
-	if getAllSearchesResp.StatusCode == 200 {
-		var searches []SearchObject
-		json.Unmarshal([]byte(getAllSearchesResp.Body), &searches)
-		for _, item := range searches {
-			if item.Name == searchName && item.Group.ID == groupId {
-				search = NewSearchObject(item.ID, item.Name, item.Query, item.Group, item.Links)
-				break
+		return nil, err
+	}
+	var search *SearchObject
+	for _, item := range searches {
+		if item.Name == reference && (groupId == 0 || item.Group.ID == groupId) {
+			if search != nil {
+				return nil, errors.New("Error: there are several searches with name " + reference +
+					", the group or the id of the search must be provided")
 			}
+			search = NewSearchObject(item.ID, item.Name, item.Query, item.Group, item.Links)
 		}
 	}
+	if search == nil {
+		return nil, newNotFoundError("Search", reference)
+	}
 	return search, nil
 }
 
+// CreateSearch creates a papertrail saved search with the name and query provided in a specific group
+func (c *Client) CreateSearch(ctx context.Context, searchName string, searchQuery string, groupId int) (*SearchObject, error) {
+	return c.createPapertrailSearchOperation(ctx, searchName, searchQuery, groupId)
+}
+
+// UpdateSearch changes the name, query and group of the papertrail saved search with the identifier provided
+func (c *Client) UpdateSearch(ctx context.Context, searchId int, searchName string, searchQuery string, groupId int) (*SearchObject, error) {
+	papertrailSearchToUpdate := SearchToCreateObject{SearchToCreate: SearchToCreate{
+		Name:    searchName,
+		Query:   searchQuery,
+		GroupID: groupId,
+	}}
+	b, err := json.Marshal(papertrailSearchToUpdate)
+	if err != nil {
+		return nil, err
+	}
+	updateSearchResp, err := c.apiOperation(ctx, "PUT", endpointWithId(papertrailApiSearchesEndpoint, int64(searchId)), bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	if updateSearchResp.StatusCode != 200 {
+		c.logf("Problems updating search with id %d\n", searchId)
+		return nil, convertStatusCodeToError(updateSearchResp, "Search", "Updating")
+	}
+	var search SearchObject
+	if err := c.decodeResponse(updateSearchResp, &search); err != nil {
+		return nil, err
+	}
+	c.logf("Search with name %s and id %d was successfully updated\n", search.Name, search.ID)
+	return &search, nil
+}
+
+// DeleteSearch deletes the papertrail saved search with the identifier provided
+func (c *Client) DeleteSearch(ctx context.Context, searchId int) error {
+	search, err := c.GetSearch(ctx, searchId)
+	if err != nil {
+		return err
+	}
+	_, err = c.deletePapertrailSearchOperation(ctx, search.Name, search.ID)
+	return err
+}
+
 // createPapertrailSearchOperation creates a papertrail search using the parameter information
 // provided as the search information to be created in a specific group
-func createPapertrailSearchOperation(searchName string, searchQuery string, groupId int) (*SearchObject, error) {
+func (c *Client) createPapertrailSearchOperation(ctx context.Context, searchName string, searchQuery string, groupId int) (*SearchObject, error) {
 	var search SearchObject
 	papertrailSearchToCreate := SearchToCreateObject{SearchToCreate: SearchToCreate{
 		Name:    searchName,
@@ -101,36 +217,40 @@ func createPapertrailSearchOperation(searchName string, searchQuery string, grou
 	if err != nil {
 		return nil, err
 	}
-	createSearchResp, err := apiOperation("POST", papertrailApiSearchesEndpoint, bytes.NewBuffer(b))
+	createSearchResp, err := c.apiOperation(ctx, "POST", papertrailApiSearchesEndpoint, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
 	if createSearchResp.StatusCode == 200 {
-		json.Unmarshal([]byte(createSearchResp.Body), &search)
-		log.Printf("Search with name %s and id %d was successfully created\n", search.Name, search.ID)
+		if err := c.decodeResponse(createSearchResp, &search); err != nil {
+			return nil, err
+		}
+		if err := checkCreatedId(createSearchResp, int64(search.ID), "search"); err != nil {
+			return nil, err
+		}
+		c.logf("Search with name %s and id %d was successfully created\n", search.Name, search.ID)
 		return &search, nil
 	}
-	log.Printf("Problems creating search with name %s in group with id %d\n", searchName, groupId)
-	err = convertStatusCodeToError(createSearchResp.StatusCode, "Search", "Creating")
+	c.logf("Problems creating search with name %s in group with id %d\n", searchName, groupId)
+	err = convertStatusCodeToError(createSearchResp, "Search", "Creating")
 	return nil, err
 }
 
 // deletePapertrailGroupOperation do the necessary calls in papertrail
 // to delete a search using the parameter information provided as the search information to be deleted
-func deletePapertrailSearchOperation(searchName string, searchId int) (*bool, error) {
+func (c *Client) deletePapertrailSearchOperation(ctx context.Context, searchName string, searchId int) (*bool, error) {
 	deleted := false
-	searchIdUrl := strings.SplitAfter(papertrailApiSearchesEndpoint, "searches")[0] +
-		"/" + strconv.Itoa(searchId) + strings.SplitAfter(papertrailApiSearchesEndpoint, "searches")[1]
-	deleteSearchResp, err := apiOperation("DELETE", searchIdUrl, nil)
+	searchIdUrl := endpointWithId(papertrailApiSearchesEndpoint, int64(searchId))
+	deleteSearchResp, err := c.apiOperation(ctx, "DELETE", searchIdUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 	if deleteSearchResp.StatusCode == 200 {
 		deleted = true
-		log.Printf("Search with name %s and id %d was successfully deleted\n", searchName, searchId)
+		c.logf("Search with name %s and id %d was successfully deleted\n", searchName, searchId)
 		return &deleted, nil
 	}
-	log.Printf("Problems deleting group with id %d\n", searchId)
-	err = convertStatusCodeToError(deleteSearchResp.StatusCode, "Search", "Deleting")
+	c.logf("Problems deleting group with id %d\n", searchId)
+	err = convertStatusCodeToError(deleteSearchResp, "Search", "Deleting")
 	return &deleted, err
 }
@@ -2,30 +2,30 @@ package papertrail
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
 	"strconv"
-	"strings"
 )
 
 // papertrailApiSystemsEndpoint represents the endpoint for interact with
 // groups in papertrail API
-const papertrailApiSystemsEndpoint = papertrailApiBaseUrl + "systems.json"
+const papertrailApiSystemsEndpoint = "systems.json"
 
 // getSystemInPapertrail obtains a papertrail system, creating it in case it does not exist previously
-func getSystemInPapertrailBasedInHostname(hostname string, destinationPort int, destinationId int,
+func (c *Client) getSystemInPapertrailBasedInHostname(ctx context.Context, hostname string, destinationPort int, destinationId int,
 	actionName string) (*Item, error) {
-	systemObject, err := checkSystemExistsBasedInHostname(hostname, destinationPort, destinationId)
+	systemObject, err := c.checkSystemExistsBasedInHostname(ctx, hostname, destinationPort, destinationId)
 	if err != nil {
 		return nil, err
 	}
 	var systemItem *Item
 	if systemObject != nil {
-		log.Printf("System with hostname %s exists with id %d\n", hostname, systemObject.ID)
+		c.logf("System with hostname %s exists with id %d\n", hostname, systemObject.ID)
 		if ActionIsCreate(actionName) {
 			systemItem = NewItem(int(systemObject.ID), "System", systemObject.Name, false, false)
 		} else if ActionIsDelete(actionName) {
-			deleted, err := deletePapertrailSystem(int(systemObject.ID))
+			deleted, err := c.deletePapertrailSystem(ctx, int(systemObject.ID))
 			if err != nil {
 				return nil, err
 			}
@@ -34,13 +34,13 @@ func getSystemInPapertrailBasedInHostname(hostname string, destinationPort int,
 			}
 		}
 	} else {
-		log.Printf("System with hostname %s doesn't exist yet\n", hostname)
+		c.logf("System with hostname %s doesn't exist yet\n", hostname)
 		if ActionIsCreate(actionName) {
 			var papertrailSystemCreated *System
 			if destinationPort != 0 {
-				papertrailSystemCreated, err = createFromHostnameAndDestinationPort(hostname, destinationPort)
+				papertrailSystemCreated, err = c.createFromHostnameAndDestinationPort(ctx, hostname, hostname, destinationPort)
 			} else {
-				papertrailSystemCreated, err = createFromHostnameAndDestinationId(hostname, destinationId)
+				papertrailSystemCreated, err = c.createFromHostnameAndDestinationId(ctx, hostname, hostname, destinationId)
 			}
 			if err != nil {
 				return nil, err
@@ -52,18 +52,18 @@ func getSystemInPapertrailBasedInHostname(hostname string, destinationPort int,
 }
 
 // getSystemInPapertrail obtains a papertrail system, creating it in case it does not exist previously
-func getSystemInPapertrailBasedInAddressIp(addressIP string, actionName string) (*Item, error) {
-	systemExists, systemObject, err := checkSystemExistsBasedInAddressIP(addressIP)
+func (c *Client) getSystemInPapertrailBasedInAddressIp(ctx context.Context, addressIP string, actionName string) (*Item, error) {
+	systemExists, systemObject, err := c.checkSystemExistsBasedInAddressIP(ctx, addressIP)
 	if err != nil {
 		return nil, err
 	}
 	var systemItem *Item
 	if (systemExists != nil) && *systemExists {
-		log.Printf("System with IPAddress %s exists with id %d\n", addressIP, systemObject.ID)
+		c.logf("System with IPAddress %s exists with id %d\n", addressIP, systemObject.ID)
 		if ActionIsCreate(actionName) || ActionIsObtain(actionName) {
 			systemItem = NewItem(int(systemObject.ID), "System", systemObject.Name, false, false)
 		} else if ActionIsDelete(actionName) {
-			deletedSystem, err := deletePapertrailSystem(int(systemObject.ID))
+			deletedSystem, err := c.deletePapertrailSystem(ctx, int(systemObject.ID))
 			if err != nil {
 				return nil, err
 			}
@@ -72,9 +72,9 @@ func getSystemInPapertrailBasedInAddressIp(addressIP string, actionName string)
 			}
 		}
 	} else if (systemExists != nil) && !*systemExists {
-		log.Printf("System with IPAddress %s doesn't exist yet\n", addressIP)
+		c.logf("System with IPAddress %s doesn't exist yet\n", addressIP)
 		if ActionIsCreate(actionName) {
-			systemItemCreated, err := createFromIPAddress(addressIP)
+			systemItemCreated, err := c.createFromIPAddress(ctx, addressIP, addressIP)
 			if err != nil {
 				return nil, err
 			}
@@ -85,50 +85,195 @@ func getSystemInPapertrailBasedInAddressIp(addressIP string, actionName string)
 }
 
 // checkSystemExists checks if a system exists in papertrail, returning the information of this one in case it exists
-func checkSystemExistsBasedInHostname(hostname string, destinationPort int, destinationId int) (*System, error) {
-	getAllSystems, err := apiOperation("GET", papertrailApiSystemsEndpoint, nil)
+func (c *Client) checkSystemExistsBasedInHostname(ctx context.Context, hostname string, destinationPort int, destinationId int) (*System, error) {
+	var system *System
+	systems, err := c.ListSystems(ctx)
 	if err != nil {
 		return nil, err
 	}
-	var system *System
-	if getAllSystems.StatusCode == 200 {
-		var systems []System
-		json.Unmarshal([]byte(getAllSystems.Body), &systems)
-		if destinationPort != 0 {
-			system = checkSystemExistsBasedInHostnameAndDestinationPort(systems, hostname, destinationPort)
+	if destinationPort != 0 {
+		system = checkSystemExistsBasedInHostnameAndDestinationPort(systems, hostname, destinationPort)
 
-		} else if destinationId != 0 {
-			destinationInfo, err := checkIfDestinationExistById(destinationId)
-			if err != nil {
-				return system, err
-			}
-			system = checkSystemExistsBasedInHostnameAndDestinationId(systems, hostname, destinationInfo)
+	} else if destinationId != 0 {
+		destinationInfo, err := c.checkIfDestinationExistById(ctx, destinationId)
+		if err != nil {
+			return system, err
 		}
+		system = checkSystemExistsBasedInHostnameAndDestinationId(systems, hostname, destinationInfo)
 	}
 	return system, nil
 }
 
 // checkSystemExists checks if a system exists in papertrail, returning the information of this one in case it exists
-func checkSystemExistsBasedInAddressIP(addressIP string) (*bool, *System, error) {
-	getAllSystems, err := apiOperation("GET", papertrailApiSystemsEndpoint, nil)
+func (c *Client) checkSystemExistsBasedInAddressIP(ctx context.Context, addressIP string) (*bool, *System, error) {
 	alreadyExists := false
+	var system *System
+	systems, err := c.ListSystems(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
-	var system *System
-	if getAllSystems.StatusCode == 200 {
-		var systems []System
-		json.Unmarshal([]byte(getAllSystems.Body), &systems)
+	for _, item := range systems {
+		if item.IPAddress == addressIP {
+			alreadyExists = true
+			system = NewSystem(item.ID, item.Name, item.LastEventAt,
+				item.AutoDelete, item.Links, item.IPAddress, item.Hostname, item.Syslog)
+			break
+		}
+	}
+	return &alreadyExists, system, nil
+}
+
+// ListSystems returns all the systems defined in papertrail
+func (c *Client) ListSystems(ctx context.Context) ([]System, error) {
+	getAllSystems, err := c.apiOperation(ctx, "GET", papertrailApiSystemsEndpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if getAllSystems.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getAllSystems, "System", "Obtaining")
+	}
+	var systems []System
+	if err := c.decodeResponse(getAllSystems, &systems); err != nil {
+		return nil, err
+	}
+	return systems, nil
+}
+
+// GetSystem returns the papertrail system with the identifier provided
+func (c *Client) GetSystem(ctx context.Context, systemId int64) (*System, error) {
+	getSystemResp, err := c.apiOperation(ctx, "GET", endpointWithId(papertrailApiSystemsEndpoint, systemId), nil)
+	if err != nil {
+		return nil, err
+	}
+	if getSystemResp.StatusCode != 200 {
+		return nil, convertStatusCodeToError(getSystemResp, "System", "Obtaining")
+	}
+	var system System
+	if err := c.decodeResponse(getSystemResp, &system); err != nil {
+		return nil, err
+	}
+	return &system, nil
+}
+
+// FindSystem returns the papertrail system whose identifier, name, hostname or IP address is the reference provided
+func (c *Client) FindSystem(ctx context.Context, reference string) (*System, error) {
+	if systemId, err := strconv.ParseInt(reference, 10, 64); err == nil {
+		return c.GetSystem(ctx, systemId)
+	}
+	systems, err := c.ListSystems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return findSystemInList(systems, reference)
+}
+
+// FindSystems returns the papertrail systems whose identifiers, names, hostnames or IP addresses are the references
+// provided, obtaining the list of systems only once. Each system is returned once even if several references match it
+func (c *Client) FindSystems(ctx context.Context, references []string) ([]System, error) {
+	systems, err := c.ListSystems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found []System
+	for _, reference := range references {
+		system, err := findSystemInList(systems, reference)
+		if err != nil {
+			return nil, err
+		}
+		duplicated := false
+		for _, item := range found {
+			duplicated = duplicated || item.ID == system.ID
+		}
+		if !duplicated {
+			found = append(found, *system)
+		}
+	}
+	return found, nil
+}
+
+// findSystemInList returns the system of the list provided whose identifier, name, hostname
+// or IP address is the reference provided, checking the identifiers first
+func findSystemInList(systems []System, reference string) (*System, error) {
+	if systemId, err := strconv.ParseInt(reference, 10, 64); err == nil {
 		for _, item := range systems {
-			if item.IPAddress == addressIP {
-				alreadyExists = true
-				system = NewSystem(item.ID, item.Name, item.LastEventAt,
-					item.AutoDelete, item.Links, item.IPAddress, item.Hostname, item.Syslog)
-				break
+			if item.ID == systemId {
+				return NewSystem(item.ID, item.Name, item.LastEventAt,
+					item.AutoDelete, item.Links, item.IPAddress, item.Hostname, item.Syslog), nil
 			}
 		}
 	}
-	return &alreadyExists, system, nil
+	var system *System
+	for _, item := range systems {
+		if item.Name == reference || item.Hostname == reference || item.IPAddress == reference {
+			if system != nil {
+				return nil, errors.New("Error: there are several systems matching " + reference +
+					", the id of the system must be provided")
+			}
+			system = NewSystem(item.ID, item.Name, item.LastEventAt,
+				item.AutoDelete, item.Links, item.IPAddress, item.Hostname, item.Syslog)
+		}
+	}
+	if system == nil {
+		return nil, newNotFoundError("System", reference)
+	}
+	return system, nil
+}
+
+// CreateSystemBasedInHostname creates a papertrail system based in hostname which sends its logs to
+// the destination port or to the destination identifier provided. If no name is provided the hostname is used
+func (c *Client) CreateSystemBasedInHostname(ctx context.Context, name string, hostname string,
+	destinationPort int, destinationId int) (*System, error) {
+	if len(name) == 0 {
+		name = hostname
+	}
+	if destinationPort != 0 && destinationId != 0 || destinationPort == 0 && destinationId == 0 {
+		return nil, errors.New("Error: it's necessary to provide either destination id or destination port ")
+	}
+	if destinationPort != 0 {
+		return c.createFromHostnameAndDestinationPort(ctx, name, hostname, destinationPort)
+	}
+	return c.createFromHostnameAndDestinationId(ctx, name, hostname, destinationId)
+}
+
+// CreateSystemBasedInIPAddress creates a papertrail system based in the IP address provided.
+// If no name is provided the IP address is used
+func (c *Client) CreateSystemBasedInIPAddress(ctx context.Context, name string, ipAddress string) (*System, error) {
+	if len(name) == 0 {
+		name = ipAddress
+	}
+	return c.createFromIPAddress(ctx, name, ipAddress)
+}
+
+// UpdateSystem changes the name, hostname, destination or auto delete option of the papertrail system with the
+// identifier provided, leaving as they are the fields not provided. The system keeps its identifier and its events
+func (c *Client) UpdateSystem(ctx context.Context, systemId int64, update SystemUpdate) (*System, error) {
+	if update.DestinationPort != 0 && update.DestinationID != 0 {
+		return nil, errors.New("Error: destination id and destination port can't be provided together ")
+	}
+	b, err := json.Marshal(NewSystemToUpdate(update))
+	if err != nil {
+		return nil, err
+	}
+	updateSystemResp, err := c.apiOperation(ctx, "PUT", endpointWithId(papertrailApiSystemsEndpoint, systemId), bytes.NewBuffer(b))
+	if err != nil {
+		return nil, err
+	}
+	if updateSystemResp.StatusCode != 200 {
+		c.logf("Problems updating system with id %d\n", systemId)
+		return nil, convertStatusCodeToError(updateSystemResp, "System", "Updating")
+	}
+	var system System
+	if err := c.decodeResponse(updateSystemResp, &system); err != nil {
+		return nil, err
+	}
+	c.logf("System with name %s and id %d was successfully updated\n", system.Name, system.ID)
+	return &system, nil
+}
+
+// DeleteSystem deletes the papertrail system with the identifier provided
+func (c *Client) DeleteSystem(ctx context.Context, systemId int64) error {
+	_, err := c.deletePapertrailSystem(ctx, int(systemId))
+	return err
 }
 
 func checkSystemExistsBasedInHostnameAndDestinationPort(systems []System, hostname string, destinationPort int) *System {
@@ -191,95 +336,115 @@ func SystemToCreateBasedInHostnameAndDestinationPort(hostname string, destinatio
 
 // createFromHostnameAndDestinationId creates a papertrail
 // system using the parameter information provided as the group information to be created
-func createFromHostnameAndDestinationId(hostname string, destinationId int) (*System, error) {
-	papertrailSystemToCreate := SystemToCreateBasedInHostnameAndDestinationId(hostname, destinationId)
+func (c *Client) createFromHostnameAndDestinationId(ctx context.Context, name string, hostname string, destinationId int) (*System, error) {
+	papertrailSystemToCreate := NewSystemToCreateBasedInHostnameToDestinationID(SystemBasedInHostname{
+		Name:     name,
+		Hostname: hostname,
+	}, destinationId)
 	b, err := json.Marshal(papertrailSystemToCreate)
 	if err != nil {
 		return nil, err
 	}
-	createSystemResp, err := apiOperation("POST", papertrailApiSystemsEndpoint, bytes.NewBuffer(b))
+	createSystemResp, err := c.apiOperation(ctx, "POST", papertrailApiSystemsEndpoint, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
 	if createSystemResp.StatusCode == 200 {
 		var system System
-		json.Unmarshal([]byte(createSystemResp.Body), &system)
-		log.Printf("System with name %s based in hostname %s was successfully "+
+		if err := c.decodeResponse(createSystemResp, &system); err != nil {
+			return nil, err
+		}
+		if err := checkCreatedId(createSystemResp, system.ID, "system"); err != nil {
+			return nil, err
+		}
+		c.logf("System with name %s based in hostname %s was successfully "+
 			"created with id %d\n", system.Name, system.Hostname, system.ID)
 		return &system, nil
 	}
-	log.Printf("Problems creating system with name %s and hostname %s\n", hostname, hostname)
-	err = convertStatusCodeToError(createSystemResp.StatusCode, "System", "Creating")
+	c.logf("Problems creating system with name %s and hostname %s\n", name, hostname)
+	err = convertStatusCodeToError(createSystemResp, "System", "Creating")
 	return nil, err
 }
 
 // createFromHostnameAndDestinationPort creates a papertrail group using the parameter information
 // provided as the system information to be created
-func createFromHostnameAndDestinationPort(hostname string, destinationPort int) (*System, error) {
-	papertrailSystemToCreate := SystemToCreateBasedInHostnameAndDestinationPort(hostname, destinationPort)
+func (c *Client) createFromHostnameAndDestinationPort(ctx context.Context, name string, hostname string, destinationPort int) (*System, error) {
+	papertrailSystemToCreate := NewSystemToCreateBasedInHostnameToDestinationPort(SystemBasedInHostname{
+		Name:     name,
+		Hostname: hostname,
+	}, destinationPort)
 	b, err := json.Marshal(papertrailSystemToCreate)
 	if err != nil {
 		return nil, err
 	}
-	createSystemResp, err := apiOperation("POST", papertrailApiSystemsEndpoint, bytes.NewBuffer(b))
+	createSystemResp, err := c.apiOperation(ctx, "POST", papertrailApiSystemsEndpoint, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
 	if createSystemResp.StatusCode == 200 {
 		var system System
-		json.Unmarshal([]byte(createSystemResp.Body), &system)
-		log.Printf("System with name %s based in hostname %s was successfully "+
+		if err := c.decodeResponse(createSystemResp, &system); err != nil {
+			return nil, err
+		}
+		if err := checkCreatedId(createSystemResp, system.ID, "system"); err != nil {
+			return nil, err
+		}
+		c.logf("System with name %s based in hostname %s was successfully "+
 			"created with id %d\n", system.Name, system.Hostname, system.ID)
 		return &system, nil
 	}
-	log.Printf("Problems creating system with name %s and hostname %s\n", hostname, hostname)
-	err = convertStatusCodeToError(createSystemResp.StatusCode, "System", "Creating")
+	c.logf("Problems creating system with name %s and hostname %s\n", name, hostname)
+	err = convertStatusCodeToError(createSystemResp, "System", "Creating")
 	return nil, err
 }
 
 // createFromIPAddress creates a papertrail system using the parameter information
 // provided as the system information to be created
-func createFromIPAddress(ipAddress string) (*System, error) {
+func (c *Client) createFromIPAddress(ctx context.Context, name string, ipAddress string) (*System, error) {
 	papertrailSystemToCreate := NewSystemToCreateBasedInIpAddress(SystemBasedInIPAddress{
-		Name:      ipAddress,
+		Name:      name,
 		IPAddress: ipAddress,
 	})
 	b, err := json.Marshal(papertrailSystemToCreate)
 	if err != nil {
 		return nil, err
 	}
-	createSystemResp, err := apiOperation("POST", papertrailApiSystemsEndpoint, bytes.NewBuffer(b))
+	createSystemResp, err := c.apiOperation(ctx, "POST", papertrailApiSystemsEndpoint, bytes.NewBuffer(b))
 	if err != nil {
 		return nil, err
 	}
 	if createSystemResp.StatusCode == 200 {
 		var system System
-		json.Unmarshal([]byte(createSystemResp.Body), &system)
-		log.Printf("System with name %s and IPAddress %s "+
+		if err := c.decodeResponse(createSystemResp, &system); err != nil {
+			return nil, err
+		}
+		if err := checkCreatedId(createSystemResp, system.ID, "system"); err != nil {
+			return nil, err
+		}
+		c.logf("System with name %s and IPAddress %s "+
 			"was successfully created with id %d\n", system.Name, system.IPAddress, system.ID)
 		return &system, nil
 	}
-	log.Printf("Problems creating system with name %s and IPAddress %s\n", ipAddress, ipAddress)
-	err = convertStatusCodeToError(createSystemResp.StatusCode, "System", "Creating")
+	c.logf("Problems creating system with name %s and IPAddress %s\n", name, ipAddress)
+	err = convertStatusCodeToError(createSystemResp, "System", "Creating")
 	return nil, err
 }
 
 // deletePapertrailSystem deletes a papertrail system using the systemId
 // provided as the system information to be deleted
-func deletePapertrailSystem(systemId int) (*bool, error) {
+func (c *Client) deletePapertrailSystem(ctx context.Context, systemId int) (*bool, error) {
 	deleted := false
-	systemIdUrl := strings.SplitAfter(papertrailApiSystemsEndpoint, "systems")[0] +
-		"/" + strconv.Itoa(systemId) + strings.SplitAfter(papertrailApiSystemsEndpoint, "systems")[1]
-	deleteSystemResp, err := apiOperation("DELETE", systemIdUrl, nil)
+	systemIdUrl := endpointWithId(papertrailApiSystemsEndpoint, int64(systemId))
+	deleteSystemResp, err := c.apiOperation(ctx, "DELETE", systemIdUrl, nil)
 	if err != nil {
 		return nil, err
 	}
 	if deleteSystemResp.StatusCode == 200 {
 		deleted = true
-		log.Printf("System with id %d was successfully deleted\n", systemId)
+		c.logf("System with id %d was successfully deleted\n", systemId)
 		return &deleted, nil
 	}
-	log.Printf("Problems deleting system with id %d\n", systemId)
-	err = convertStatusCodeToError(deleteSystemResp.StatusCode, "System", "Creating")
+	c.logf("Problems deleting system with id %d\n", systemId)
+	err = convertStatusCodeToError(deleteSystemResp, "System", "Deleting")
 	return &deleted, err
 }
@@ -3,13 +3,8 @@ package papertrail
 import (
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
-	"log"
-	"net/http"
-	"os"
 	"regexp"
-	"strconv"
+	"time"
 )
 
 // CheckValidActionsConditions checks if a valid value is being used for the action parameter
@@ -25,46 +20,54 @@ func CheckValidActionsConditions(action string) error {
 	return nil
 }
 
-// checkStartdateAndEndDateFormat checks if startDate and endDate complish
-// date format
-func checkStDateEndDateFmt(startDate string, endDate string) error {
-	err := CheckDateFormat(startDate)
-	if err != nil {
-		return fmt.Errorf("cannot parse startdate: %v", err)
-	}
-	err = CheckDateFormat(endDate)
-	if err != nil {
-		return fmt.Errorf("cannot parse enddate: %v", err)
-	}
-	return nil
-}
-
 // convertStartDateAndEndDateToUnixFormat converts startDate and endDate
 // parameters from string to unix timestamp in seconds
 func cnvStDateEndDateToUnixTime(startDate string, endDate string) (int64, int64, error) {
-	err := checkStDateEndDateFmt(startDate, endDate)
+	return cnvStDateEndDateToUnixTimeInLocation(startDate, endDate, time.Now(), time.UTC)
+}
+
+// cnvStDateEndDateToUnixTimeInLocation converts startDate and endDate parameters from string to unix
+// timestamp in seconds, interpreting the relative expressions from the same time provided as now
+// and the dates without time zone in the location provided
+func cnvStDateEndDateToUnixTimeInLocation(startDate string, endDate string, now time.Time, loc *time.Location) (int64, int64, error) {
+	start, err := ParseDate(startDate, now, loc)
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, fmt.Errorf("cannot parse startdate: %v", err)
 	}
-	startDateUnix, err := GetTimeStampUnixFromDate(startDate)
+	end, err := ParseDate(endDate, now, loc)
 	if err != nil {
-		return 0, 0, err
+		return 0, 0, fmt.Errorf("cannot parse enddate: %v", err)
 	}
-	endDateUnix, err := GetTimeStampUnixFromDate(endDate)
+	return start.Unix(), end.Unix(), nil
+}
+
+// GetDateRangeUnixTime converts the start and end dates provided (UTC time if they don't include the time zone)
+// to unix timestamps in seconds, checking that the start date is not after the end date
+func GetDateRangeUnixTime(startDate string, endDate string) (int64, int64, error) {
+	return GetDateRangeUnixTimeInLocation(startDate, endDate, time.UTC)
+}
+
+// GetDateRangeUnixTimeInLocation converts the start and end dates provided to unix timestamps in seconds, checking
+// that the start date is not after the end date. The dates can be expressions relative to the current time like '-2h'
+// or 'yesterday' (see ParseDate), and the ones without time zone are interpreted in the location provided
+func GetDateRangeUnixTimeInLocation(startDate string, endDate string, loc *time.Location) (int64, int64, error) {
+	startDateUnix, endDateUnix, err := cnvStDateEndDateToUnixTimeInLocation(startDate, endDate, time.Now(), loc)
 	if err != nil {
 		return 0, 0, err
 	}
+	if startDateUnix > endDateUnix {
+		return 0, 0, fmt.Errorf("startdate > enddate - please set proper data boundaries")
+	}
 	return startDateUnix, endDateUnix, nil
 }
 
 // checkNecessaryConditions checks if the conditions to provide a token to interact
 // with papertrail are met, as well as that a valid action is provided (c/create, d/delete or o/obtain)
 // and the dates provided are valid
-func checkNecessaryConditions(action string, systemType string, ipAddress string,
+func checkNecessaryConditions(papertrailToken string, action string, systemType string, ipAddress string,
 	destinationId int, destinationPort int, startDate int64, endDate int64) error {
-	// Token necessary for interact with papertrail is obtained
-	// from environment variable with name PAPERTRAIL_API_TOKEN
-	papertrailToken := os.Getenv("PAPERTRAIL_API_TOKEN")
+	// Token necessary for interact with papertrail is obtained from the client,
+	// by default from environment variable with name PAPERTRAIL_API_TOKEN
 	if len(papertrailToken) == 0 {
 		return errors.New("Error getting value of PAPERTRAIL_API_TOKEN, " +
 			"it's necessary to define this variable with your papertrail's API token ")
@@ -83,35 +86,6 @@ func checkNecessaryConditions(action string, systemType string, ipAddress string
 	return nil
 }
 
-// apiOperation is a generic function to interact with the papertrail API, in which
-// a series of headers necessary for the interaction with this API are established.
-// Through the parameters it is possible to indicate the type of operation, the body to be sent
-// and the specific URL of the API
-func apiOperation(method string, url string, bodyToSend io.Reader) (*ApiResponse, error) {
-	req, err := http.NewRequest(method, url, bodyToSend)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Add(papertrailTokenName, os.Getenv("PAPERTRAIL_API_TOKEN"))
-	req.Header.Add("Content-Type", "application/json")
-	// Send req using http Client
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-	return &ApiResponse{
-		Body:       body,
-		StatusCode: resp.StatusCode,
-		err:        err,
-	}, nil
-}
-
 // systemTypeIsHostname checks if the type of system entered
 // is hostname for the system-type parameter
 func systemTypeIsHostname(systemType string) bool {
@@ -211,7 +185,7 @@ func find(slice []string, val string) (int, bool) {
 // fulfill the condition of created or removed to the first list
 func getOnlyElementsCreatedOrRemovedDistinctEventSearch(papertrailToAddItems []Item, createdOrRemovedItems []Item) []Item {
 	for _, item := range papertrailToAddItems {
-		if item.Deleted || item.Created || item.ItemType == "EventsSearch" {
+		if item.Deleted || item.Created || item.Updated || item.ItemType == "EventsSearch" {
 			createdOrRemovedItems = append(createdOrRemovedItems, item)
 		}
 	}
@@ -228,7 +202,7 @@ func addItemsToCreatedOrDeletedItems(papertrailToAddItems []Item, papertrailItem
 // execution or not, if it has been created/deleted it is added to the list of created items
 func addItemToCreatedOrDeletedItems(papertrailToAddItem Item, papertrailItemsCreatedOrDeleted []Item) []Item {
 	var newItems []Item
-	if papertrailToAddItem.Deleted || papertrailToAddItem.Created ||
+	if papertrailToAddItem.Deleted || papertrailToAddItem.Created || papertrailToAddItem.Updated ||
 		papertrailToAddItem.ItemType == "EventsSearch" {
 		newItems = append(papertrailItemsCreatedOrDeleted, papertrailToAddItem)
 	} else {
@@ -237,14 +211,6 @@ func addItemToCreatedOrDeletedItems(papertrailToAddItem Item, papertrailItemsCre
 	return newItems
 }
 
-// convertStatusCodeToError converts an error according to the status code provided
-func convertStatusCodeToError(statusCode int, resource string, action string) error {
-	if statusCode == 404 {
-		return errors.New("Error: " + resource + " not found ")
-	}
-	return errors.New("Error: " + action + " " + resource + " Status Code " + strconv.Itoa(statusCode) + " received ")
-}
-
 // checkConditionsForDeleteAllSystems checks if all papertrail systems should be deleted
 func checkConditionsForDeleteAllSystems(actionName string, deleteAllSystems bool) bool {
 	if !ActionIsDelete(actionName) {
@@ -265,16 +231,16 @@ func checkConditionsForSkipGroupSearch(actionName string, deleteAllSystems bool)
 }
 
 // printMessageActionDelete prints the possible message when the action to perform is delete
-func printMessageActionDelete(options Options) {
+func (c *Client) printMessageActionDelete(options Options) {
 	if options.DeleteOnlySystems {
-		log.Printf("Checking conditions for do action '%s' in papertrail params: "+
+		c.logf("Checking conditions for do action '%s' in papertrail params: "+
 			"[--group-name %s] [--system-wildcard %s] [--delete-all-searches %t] "+
 			"[--delete-only-searches %t] [--delete-all-systems %t] [--delete-only-systems %t]\n",
 			options.Action, options.GroupName, options.SystemWildcard,
 			options.DeleteAllSearches, options.DeleteOnlySearches, options.DeleteAllSystems,
 			options.DeleteOnlySystems)
 	} else {
-		log.Printf("Checking conditions for do action '%s' in papertrail params: "+
+		c.logf("Checking conditions for do action '%s' in papertrail params: "+
 			"[--group-name %s] [--system-wildcard %s] [--search %s] [--delete-all-searches %t] "+
 			"[--delete-only-searches %t] [--delete-all-systems %t] [--delete-only-systems %t]\n",
 			options.Action, options.GroupName, options.SystemWildcard, options.Search,
@@ -284,27 +250,27 @@ func printMessageActionDelete(options Options) {
 }
 
 // printMessageActionCreate prints the possible message when the action to perform is create
-func printMessageActionCreate(options Options) {
+func (c *Client) printMessageActionCreate(options Options) {
 	if options.DestinationId != 0 {
-		log.Printf("Checking conditions for do action '%s' in papertrail params: "+
+		c.logf("Checking conditions for do action '%s' in papertrail params: "+
 			"[--group-name %s] [--system-wildcard %s] [--destination-id %d] [--search %s] [--query %s]\n",
 			options.Action, options.GroupName, options.SystemWildcard, options.DestinationId,
 			options.Search, options.Query)
 	} else if options.DestinationPort != 0 {
-		log.Printf("Checking conditions for do action '%s' in papertrail params: "+
+		c.logf("Checking conditions for do action '%s' in papertrail params: "+
 			"[--group-name %s] [--system-wildcard %s] [--destination-port %d] [--search %s] [--query %s]\n",
 			options.Action, options.GroupName, options.SystemWildcard, options.DestinationPort,
 			options.Search, options.Query)
 	} else {
-		log.Printf("Checking conditions for do action '%s' in papertrail params: "+
+		c.logf("Checking conditions for do action '%s' in papertrail params: "+
 			"[--group-name %s] [--system-wildcard %s] [--search %s] [--query %s]\n",
 			options.Action, options.GroupName, options.SystemWildcard, options.Search, options.Query)
 	}
 }
 
 // printMessageActionObtain prints the possible message when the action to perform is obtain
-func printMessageActionObtain(options Options) {
-	log.Printf("Checking conditions for do action '%s' in papertrail params: "+
+func (c *Client) printMessageActionObtain(options Options) {
+	c.logf("Checking conditions for do action '%s' in papertrail params: "+
 		"[--group-name %s] [--system-wildcard %s] [--search %s] [--query %s] "+
 		"[--start-date %s] [--end-date %s] [--path %s]\n",
 		options.Action, options.GroupName, options.SystemWildcard, options.Search,
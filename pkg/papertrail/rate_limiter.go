@@ -0,0 +1,155 @@
+package papertrail
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Headers used by papertrail to inform about the rate limit of the account
+const (
+	rateLimitLimitHeader     = "X-Rate-Limit-Limit"
+	rateLimitRemainingHeader = "X-Rate-Limit-Remaining"
+	rateLimitResetHeader     = "X-Rate-Limit-Reset"
+)
+
+// maxRetryDelay is the maximum time to wait between two attempts of the same request
+const maxRetryDelay = 30 * time.Second
+
+// RateLimiter keeps track of the request budget reported by papertrail through the
+// X-Rate-Limit-* headers, making the requests wait until the budget is reset when it
+// runs out. It is safe for concurrent use, so it can be shared by several clients
+// of the same papertrail account
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	reset     time.Time
+	known     bool
+}
+
+// NewRateLimiter allows to create a RateLimiter type struct, the budget is
+// unknown until the first response from papertrail is received
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{}
+}
+
+// Update updates the request budget using the headers of a papertrail's response,
+// the responses without rate limit headers are ignored
+func (r *RateLimiter) Update(header http.Header) {
+	remaining, err := strconv.Atoi(header.Get(rateLimitRemainingHeader))
+	if err != nil {
+		return
+	}
+	resetSeconds, err := strconv.Atoi(header.Get(rateLimitResetHeader))
+	if err != nil {
+		return
+	}
+	limit, _ := strconv.Atoi(header.Get(rateLimitLimitHeader))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limit = limit
+	r.remaining = remaining
+	r.reset = time.Now().Add(time.Duration(resetSeconds) * time.Second)
+	r.known = true
+}
+
+// Wait blocks until there is budget to send a new request or the context is done
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	for {
+		delay := r.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve consumes one request of the budget if there is any left, otherwise it
+// returns the time remaining until the budget is reset
+func (r *RateLimiter) reserve() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.known {
+		return 0
+	}
+	now := time.Now()
+	if !now.Before(r.reset) {
+		// The budget has been reset, it will be known again with the next response
+		r.known = false
+		return 0
+	}
+	if r.remaining > 0 {
+		r.remaining--
+		return 0
+	}
+	return r.reset.Sub(now)
+}
+
+// untilReset returns the time remaining until the budget is reset, zero if it is unknown
+func (r *RateLimiter) untilReset() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.known {
+		return 0
+	}
+	return time.Until(r.reset)
+}
+
+// isRetryableStatusCode checks if a request answered with the status code provided can be retried.
+// Rate limited requests and unavailable services are always retried, while the rest of transient
+// server errors are only retried for idempotent methods, to avoid creating elements twice
+func isRetryableStatusCode(method string, statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete
+	}
+	return false
+}
+
+// retryDelay returns the time to wait before the next attempt of a request, using the information
+// of the rate limit for the rate limited requests and an exponential backoff with jitter otherwise
+func retryDelay(attempt int, baseDelay time.Duration, statusCode int, header http.Header, limiter *RateLimiter) time.Duration {
+	if statusCode == http.StatusTooManyRequests {
+		if retryAfter, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+			return time.Duration(retryAfter)*time.Second + jitter(baseDelay)
+		}
+		if limiter != nil {
+			if untilReset := limiter.untilReset(); untilReset > 0 {
+				return untilReset + jitter(baseDelay)
+			}
+		}
+	}
+	delay := baseDelay << uint(attempt)
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay/2 + jitter(delay/2)
+}
+
+// jitter returns a random duration between zero and the duration provided
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
+// sleepContext waits for the duration provided, returning earlier if the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
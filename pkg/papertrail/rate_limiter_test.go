@@ -0,0 +1,92 @@
+package papertrail
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestClient creates a client for the server provided that doesn't wait between retries
+func newTestClient(serverURL string, token string) *Client {
+	client := NewClient(serverURL+"/api/v1/", token, nil, "", log.New(ioutil.Discard, "", 0))
+	client.RetryBaseDelay = time.Millisecond
+	return client
+}
+
+func TestClient_ApiOperationRetriesRateLimitedRequests(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.Header().Set(rateLimitLimitHeader, "25")
+			w.Header().Set(rateLimitRemainingHeader, "0")
+			w.Header().Set(rateLimitResetHeader, "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+	resp, err := newTestClient(server.URL, "token").apiOperation(context.Background(), "GET", papertrailApiGroupsEndpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&requests) != 3 {
+		t.Fatalf("expected success after 3 requests, obtained status %d after %d requests", resp.StatusCode, requests)
+	}
+}
+
+func TestClient_ApiOperationDoesNotRetryCreationsOnServerErrors(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	client := newTestClient(server.URL, "token")
+	resp, err := client.apiOperation(context.Background(), "POST", papertrailApiGroupsEndpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError || atomic.LoadInt32(&requests) != 1 {
+		t.Fatalf("expected a single request, obtained %d", requests)
+	}
+	_, err = client.apiOperation(context.Background(), "GET", papertrailApiGroupsEndpoint, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := int32(1 + 1 + client.MaxRetries); atomic.LoadInt32(&requests) != expected {
+		t.Fatalf("expected %d requests, obtained %d", expected, requests)
+	}
+}
+
+func TestRateLimiter_WaitUntilReset(t *testing.T) {
+	limiter := NewRateLimiter()
+	header := http.Header{}
+	header.Set(rateLimitLimitHeader, "25")
+	header.Set(rateLimitRemainingHeader, "1")
+	header.Set(rateLimitResetHeader, "1")
+	limiter.Update(header)
+	start := time.Now()
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("the first request should not wait while there is budget left")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected to wait for the reset of the budget, obtained %v", err)
+	}
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 900*time.Millisecond {
+		t.Fatal("the request should have waited until the budget was reset")
+	}
+}
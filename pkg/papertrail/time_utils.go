@@ -2,16 +2,32 @@ package papertrail
 
 import (
 	"errors"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const shortDateFormat = "01/02/2006 15:04:05"
 
+// dateLayouts are the layouts accepted for the dates besides the legacy one and RFC 3339, interpreted in the time zone provided
+var dateLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006",
+}
+
+// clockLayouts are the layouts accepted for the time of the day following 'today' or 'yesterday'
+var clockLayouts = []string{
+	"15:04:05",
+	"15:04",
+}
+
 // GetTimeStampUnixFromDate returns the date provided as a
 // parameter in timestamp format to date using the layout
 func GetTimeStampUnixFromDate(date string) (int64, error) {
-	layout := "01/02/2006 15:04:05"
-	t, err := time.Parse(layout, date)
+	t, err := ParseDate(date, time.Now(), time.UTC)
 	if err != nil {
 		return 0, errors.New("Error converting date " + date + "to timestamp ")
 	}
@@ -25,9 +41,112 @@ func GetTimeInUTCFromUnixTime(timestamp int64) string {
 
 // CheckDateFormat check if date complish format specified
 func CheckDateFormat(ds string) error {
-	_, err := time.Parse(shortDateFormat, ds)
+	_, err := ParseDate(ds, time.Now(), time.UTC)
+	return err
+}
+
+// ParseDate returns the time corresponding to the date provided, which can be in 'mm/dd/yyyy hh:mm:ss',
+// RFC 3339, 'yyyy-mm-dd hh:mm:ss' or 'yyyy-mm-dd' format, or an expression relative to the time provided
+// as now: 'now', '-2h', '30m ago', '1d ago', 'yesterday' or 'today 09:00'. The dates without time zone
+// are interpreted in the location provided, UTC if it is nil. When the date is not valid, the error
+// is the one obtained parsing it in 'mm/dd/yyyy hh:mm:ss' format
+func ParseDate(date string, now time.Time, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	value := strings.TrimSpace(date)
+	if t, err := time.ParseInLocation(shortDateFormat, value, loc); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	if t, ok := parseRelativeDate(strings.ToLower(value), now.In(loc)); ok {
+		return t, nil
+	}
+	_, err := time.ParseInLocation(shortDateFormat, date, loc)
+	return time.Time{}, err
+}
+
+// parseRelativeDate returns the time corresponding to an expression relative to the time provided as now,
+// indicating if the expression is valid
+func parseRelativeDate(expr string, now time.Time) (time.Time, bool) {
+	fields := strings.Fields(expr)
+	switch {
+	case expr == "now":
+		return now, true
+	case len(fields) > 0 && (fields[0] == "today" || fields[0] == "yesterday"):
+		day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		if fields[0] == "yesterday" {
+			day = day.AddDate(0, 0, -1)
+		}
+		if len(fields) == 1 {
+			return day, true
+		} else if len(fields) > 2 {
+			return time.Time{}, false
+		}
+		for _, layout := range clockLayouts {
+			if clock, err := time.Parse(layout, fields[1]); err == nil {
+				return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0,
+					day.Location()), true
+			}
+		}
+	case strings.HasSuffix(expr, " ago"):
+		if d, err := ParseRelativeDuration(strings.TrimSuffix(expr, " ago")); err == nil {
+			return now.Add(-d), true
+		}
+	case strings.HasPrefix(expr, "-"):
+		if d, err := ParseRelativeDuration(expr[1:]); err == nil {
+			return now.Add(-d), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseRelativeDuration returns the duration provided, which besides the units accepted by
+// time.ParseDuration can be expressed in days or weeks, e.g. '15m', '1h30m', '2d' or '1w'
+func ParseRelativeDuration(duration string) (time.Duration, error) {
+	value := strings.TrimSpace(duration)
+	if strings.HasSuffix(value, "d") || strings.HasSuffix(value, "w") {
+		if count, err := strconv.Atoi(value[:len(value)-1]); err == nil && count >= 0 {
+			day := 24 * time.Hour
+			if strings.HasSuffix(value, "w") {
+				return time.Duration(count) * 7 * day, nil
+			}
+			return time.Duration(count) * day, nil
+		}
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, errors.New("Error: not valid duration " + duration + ", e.g. 15m, 1h30m, 2d or 1w ")
+	}
+	return d, nil
+}
+
+// LoadTimeZone returns the location with the name provided, which can be a name of the IANA Time Zone
+// database like 'Europe/Madrid', 'UTC', 'Local' or an offset from UTC like '+02:00'. UTC is returned
+// if no name is provided
+func LoadTimeZone(name string) (*time.Location, error) {
+	value := strings.TrimSpace(name)
+	if len(value) == 0 {
+		return time.UTC, nil
+	}
+	if strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-") {
+		for _, layout := range []string{"-07:00", "-0700", "-07"} {
+			if t, err := time.Parse(layout, value); err == nil {
+				_, offset := t.Zone()
+				return time.FixedZone(value, offset), nil
+			}
+		}
+	}
+	loc, err := time.LoadLocation(value)
 	if err != nil {
-		return err
+		return nil, errors.New("Error: not valid time zone " + name + ", e.g. UTC, Local, Europe/Madrid or +02:00 ")
 	}
-	return nil
+	return loc, nil
 }
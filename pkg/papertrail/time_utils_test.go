@@ -0,0 +1,77 @@
+package papertrail
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	madrid := time.FixedZone("+02:00", 2*3600)
+	now := time.Date(2020, 8, 4, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		date     string
+		loc      *time.Location
+		expected time.Time
+	}{
+		{"08/04/2020 09:00:00", nil, time.Date(2020, 8, 4, 9, 0, 0, 0, time.UTC)},
+		{"08/04/2020 09:00:00", madrid, time.Date(2020, 8, 4, 7, 0, 0, 0, time.UTC)},
+		{"2020-08-04T09:00:00+01:00", madrid, time.Date(2020, 8, 4, 8, 0, 0, 0, time.UTC)},
+		{"2020-08-04 09:00", madrid, time.Date(2020, 8, 4, 7, 0, 0, 0, time.UTC)},
+		{"2020-08-04", nil, time.Date(2020, 8, 4, 0, 0, 0, 0, time.UTC)},
+		{"now", nil, now},
+		{"-2h", nil, now.Add(-2 * time.Hour)},
+		{"30m ago", nil, now.Add(-30 * time.Minute)},
+		{"1d ago", nil, now.Add(-24 * time.Hour)},
+		{"today", nil, time.Date(2020, 8, 4, 0, 0, 0, 0, time.UTC)},
+		{"Yesterday", nil, time.Date(2020, 8, 3, 0, 0, 0, 0, time.UTC)},
+		{"today 09:00", madrid, time.Date(2020, 8, 4, 7, 0, 0, 0, time.UTC)},
+		{"yesterday 23:59:59", nil, time.Date(2020, 8, 3, 23, 59, 59, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		date, err := ParseDate(test.date, now, test.loc)
+		if err != nil {
+			t.Errorf("unexpected error parsing %s: %v", test.date, err)
+		} else if !date.Equal(test.expected) {
+			t.Errorf("expected %v parsing %s, obtained %v", test.expected, test.date, date)
+		}
+	}
+	for _, date := range []string{"", "tomorrow", "2h", "today 25:00", "-2x", "14/08/2020 10:20:00"} {
+		if _, err := ParseDate(date, now, nil); err == nil {
+			t.Errorf("expected an error parsing %s", date)
+		}
+	}
+}
+
+func TestParseRelativeDuration(t *testing.T) {
+	tests := map[string]time.Duration{"15m": 15 * time.Minute, "1h30m": 90 * time.Minute, "2d": 48 * time.Hour, "1w": 168 * time.Hour}
+	for duration, expected := range tests {
+		d, err := ParseRelativeDuration(duration)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d != expected {
+			t.Errorf("expected %v for %s, obtained %v", expected, duration, d)
+		}
+	}
+	for _, duration := range []string{"", "15", "-1h", "1.5d", "1y"} {
+		if _, err := ParseRelativeDuration(duration); err == nil {
+			t.Errorf("expected an error for the duration %s", duration)
+		}
+	}
+}
+
+func TestLoadTimeZone(t *testing.T) {
+	tests := map[string]int{"": 0, "UTC": 0, "+02:00": 2 * 3600, "-0530": -(5*3600 + 30*60)}
+	for name, expected := range tests {
+		loc, err := LoadTimeZone(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, offset := time.Date(2020, 1, 1, 0, 0, 0, 0, loc).Zone(); offset != expected {
+			t.Errorf("expected an offset of %d for %s, obtained %d", expected, name, offset)
+		}
+	}
+	if _, err := LoadTimeZone("Mars/Olympus_Mons"); err == nil {
+		t.Error("expected an error for an unknown time zone")
+	}
+}
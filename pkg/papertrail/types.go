@@ -1,6 +1,10 @@
 package papertrail
 
-import "time"
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+)
 
 // Options contains all the app possible options.
 type Options struct {
@@ -52,6 +56,18 @@ type Options struct {
 
 	// Path where to store the logs
 	Path string
+
+	// Formatter used to write the log events obtained, only their messages are written if it is not provided
+	EventsFormatter EventsFormatter
+
+	// Indicates if the existing group and search whose system wildcard or query differ from the ones provided
+	// are left as they are when the action is create, only warning about the differences instead of updating them
+	NoUpdate bool
+
+	// Indicate if the system wildcard and the query have been provided explicitly instead of taking their default
+	// values, the existing group and search are only compared with them and updated when they have been provided
+	SystemWildcardProvided bool
+	QueryProvided          bool
 }
 
 // Self object used by papertrail to identify a Self object
@@ -185,19 +201,33 @@ func NewSearchObject(ID int, name string, query string, group SearchGroup, links
 
 // ApiResponse represents the information collected about a response of papertrail's API requests
 type ApiResponse struct {
+	Method     string
+	URL        string
 	Body       []byte
 	StatusCode int
-	err        error
 }
 
 // Item is the structure used to represent the different papertrail elements
 // on which some action is performed during the execution of the cli
 type Item struct {
-	ID       int
-	ItemType string
-	ItemName string
-	Created  bool
-	Deleted  bool
+	ID       int    `json:"id"`
+	ItemType string `json:"type"`
+	ItemName string `json:"name"`
+	Created  bool   `json:"created"`
+	Deleted  bool   `json:"deleted"`
+	Updated  bool   `json:"updated,omitempty"`
+
+	// Values of the fields changed, only for the elements updated
+	Changes []FieldChange `json:"changes,omitempty"`
+
+	// Path of the file where the events have been saved, only for events searches
+	FilePath string `json:"file_path,omitempty"`
+
+	// Number of events saved in the file, only for events searches
+	EventCount int `json:"event_count,omitempty"`
+
+	// Whether papertrail reported that not all the events were returned, only for events searches
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // NewItem allows to create a Item type struct providing all the information for it
@@ -205,6 +235,38 @@ func NewItem(ID int, itemType string, itemName string, created bool, deleted boo
 	return &Item{ID: ID, ItemType: itemType, ItemName: itemName, Created: created, Deleted: deleted}
 }
 
+// NewUpdatedItem allows to create the Item type struct representing an element updated
+// during the execution, providing the values of the fields changed
+func NewUpdatedItem(ID int, itemType string, itemName string, changes []FieldChange) *Item {
+	return &Item{ID: ID, ItemType: itemType, ItemName: itemName, Updated: true, Changes: changes}
+}
+
+// NewEventsSearchItem allows to create the Item type struct representing the events
+// of a search saved in a file, providing the path of the file and the number of events
+func NewEventsSearchItem(filePath string, eventCount int) *Item {
+	return &Item{
+		ItemType:   "EventsSearch",
+		ItemName:   filePath + " with " + strconv.Itoa(eventCount) + " events retrieved",
+		FilePath:   filePath,
+		EventCount: eventCount,
+	}
+}
+
+// MarshalJSON encodes the item as JSON, always including the file
+// path and the number of events in the items of events searches
+func (i Item) MarshalJSON() ([]byte, error) {
+	type item Item
+	if i.ItemType != "EventsSearch" {
+		return json.Marshal(item(i))
+	}
+	return json.Marshal(struct {
+		item
+		FilePath   string `json:"file_path"`
+		EventCount int    `json:"event_count"`
+		Truncated  bool   `json:"truncated"`
+	}{item(i), i.FilePath, i.EventCount, i.Truncated})
+}
+
 // SystemBasedInHostname is the structure used to represent the information
 // of a hostname based papertrail system
 type SystemBasedInHostname struct {
@@ -257,6 +319,40 @@ func NewSystemToCreateBasedInIpAddress(system SystemBasedInIPAddress) *SystemToC
 	return &SystemToCreateBasedInIpAddress{System: system}
 }
 
+// SystemUpdate is the structure used to indicate the changes to be made on a papertrail system,
+// the fields with their zero value are left as they are
+type SystemUpdate struct {
+	Name            string
+	Hostname        string
+	DestinationPort int
+	DestinationID   int
+	AutoDelete      *bool
+}
+
+// SystemFieldsToUpdate is the structure used to represent the fields of a papertrail system to be updated
+type SystemFieldsToUpdate struct {
+	Name       string `json:"name,omitempty"`
+	Hostname   string `json:"hostname,omitempty"`
+	AutoDelete *bool  `json:"auto_delete,omitempty"`
+}
+
+// SystemToUpdate is the structure used to represent a system to be updated, including
+// the destination to which it sends its logs if it changes
+type SystemToUpdate struct {
+	System          SystemFieldsToUpdate `json:"system"`
+	DestinationID   int                  `json:"destination_id,omitempty"`
+	DestinationPort int                  `json:"destination_port,omitempty"`
+}
+
+// NewSystemToUpdate allows to create a SystemToUpdate type struct from the changes to be made on a system
+func NewSystemToUpdate(update SystemUpdate) *SystemToUpdate {
+	return &SystemToUpdate{
+		System:          SystemFieldsToUpdate{Name: update.Name, Hostname: update.Hostname, AutoDelete: update.AutoDelete},
+		DestinationID:   update.DestinationID,
+		DestinationPort: update.DestinationPort,
+	}
+}
+
 // Events is the structure used to represent the information of the events obtained in a papertrail search
 type Events struct {
 	ID                string    `json:"id"`
@@ -282,12 +378,13 @@ type EventsSearch struct {
 	ReachedBeginning   bool      `json:"reached_beginning"`
 	MinTimeAt          time.Time `json:"min_time_at"`
 	ReachedRecordLimit bool      `json:"reached_record_limit"`
+	ReachedTimeLimit   bool      `json:"reached_time_limit"`
 }
 
 // EventsSearchRequestWithMinAndMaxTime represents the information used to request events
 // from a search without providing the parameters for minimum and maximum time
 type EventsSearchRequestWithMinAndMaxTime struct {
-	GroupID int    `json:"group_id"`
+	GroupID int    `json:"group_id,omitempty"`
 	Q       string `json:"q"`
 	MinTime string `json:"min_time"`
 	MaxTime string `json:"max_time"`
@@ -302,7 +399,7 @@ func NewEventsSearchRequestWithMinAndMaxTime(groupID int, q string, minTime stri
 // EventsSearchRequestWithMinTimeMaxId represents the information used to request events
 // from a search providing the parameters for minimum time and the maximum id
 type EventsSearchRequestWithMinTimeMaxId struct {
-	GroupID int    `json:"group_id"`
+	GroupID int    `json:"group_id,omitempty"`
 	Q       string `json:"q"`
 	MinTime string `json:"min_time"`
 	MaxId   string `json:"max_id"`
@@ -313,3 +410,20 @@ type EventsSearchRequestWithMinTimeMaxId struct {
 func NewEventsSearchRequestWithMinTimeMaxId(groupID int, q string, minTime string, maxId string) *EventsSearchRequestWithMinTimeMaxId {
 	return &EventsSearchRequestWithMinTimeMaxId{GroupID: groupID, Q: q, MinTime: minTime, MaxId: maxId}
 }
+
+// EventsSearchRequest represents the information used to request events from a search,
+// only the parameters provided are sent
+type EventsSearchRequest struct {
+	GroupID  int    `json:"group_id,omitempty"`
+	SystemID int64  `json:"system_id,omitempty"`
+	Q        string `json:"q,omitempty"`
+	MinID    string `json:"min_id,omitempty"`
+	MaxID    string `json:"max_id,omitempty"`
+	MinTime  string `json:"min_time,omitempty"`
+	MaxTime  string `json:"max_time,omitempty"`
+}
+
+// NewEventsSearchRequest allows to create a EventsSearchRequest type struct providing all the information for it
+func NewEventsSearchRequest(groupID int, q string, minId string, maxId string, minTime string, maxTime string) *EventsSearchRequest {
+	return &EventsSearchRequest{GroupID: groupID, Q: q, MinID: minId, MaxID: maxId, MinTime: minTime, MaxTime: maxTime}
+}
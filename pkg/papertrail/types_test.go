@@ -0,0 +1,32 @@
+package papertrail
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestItem_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		item     Item
+		expected string
+	}{
+		{
+			item:     *NewItem(7, "Group", "group-test", true, false),
+			expected: `{"id":7,"type":"Group","name":"group-test","created":true,"deleted":false}`,
+		},
+		{
+			item: *NewEventsSearchItem("/tmp/group-test_search", 0),
+			expected: `{"id":0,"type":"EventsSearch","name":"/tmp/group-test_search with 0 events retrieved",` +
+				`"created":false,"deleted":false,"file_path":"/tmp/group-test_search","event_count":0,"truncated":false}`,
+		},
+	}
+	for _, test := range tests {
+		b, err := json.Marshal(test.item)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.expected {
+			t.Errorf("expected %s, obtained %s", test.expected, b)
+		}
+	}
+}